@@ -1,24 +1,68 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"gokube/pkg/api"
 	"gokube/pkg/api/server"
+	"gokube/pkg/clock"
+	"gokube/pkg/metrics"
+	"gokube/pkg/notify"
+	"gokube/pkg/registry"
 	"gokube/pkg/storage"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 )
 
 var (
 	address        string
+	tlsCertFile    string
+	tlsKeyFile     string
+	clientCAFile   string
 	etcdPeerPort   int
 	etcdClientPort int
+	compressValues bool
+	strictTypeMeta bool
+	strictQuery    bool
+	readOnly       bool
+	maxPageBytes   int
+	bootstrapAuth  bool
+	skipSelfTest   bool
+	errorVerbosity string
+
+	compactionInterval    time.Duration
+	compactionKeep        int
+	compactionKeepFor     time.Duration
+	compactionClusterWide bool
+
+	defaultNodeLabels  map[string]string
+	cordonedNodeRoles  []string
+	eventLogPath       string
+	eventTTL           time.Duration
+	tombstoneTTL       time.Duration
+	purgeInterval      time.Duration
+	normalizeNodeNames bool
+	requiredLabels     []string
+	admissionPlugins   []string
+	watchCoalesce      time.Duration
+	watchHeartbeat     time.Duration
+	streamIdleTimeout  time.Duration
+
+	notifyURL        string
+	notifyAnnotation string
+
+	metricsNodeLabel bool
+	metricsLatency   bool
 )
 
 func main() {
@@ -34,8 +78,40 @@ func main() {
 	}
 
 	rootCmd.Flags().StringVar(&address, "address", ":8080", `The address to serve on (default ":8080")`)
+	rootCmd.Flags().StringVar(&tlsCertFile, "tls-cert-file", "", `File with the certificate to serve HTTPS with; empty serves plain HTTP`)
+	rootCmd.Flags().StringVar(&tlsKeyFile, "tls-private-key-file", "", `File with the private key of --tls-cert-file`)
+	rootCmd.Flags().StringVar(&clientCAFile, "client-ca-file", "", `File with the CAs that sign operator and node client certificates; requires --tls-cert-file`)
 	rootCmd.Flags().IntVar(&etcdPeerPort, "etcd-peer-port", 0, `The port to start etcd peer on (default random port)`)
 	rootCmd.Flags().IntVar(&etcdClientPort, "etcd-client-port", 2379, `The port to start etcd client on (default 2379)`)
+	rootCmd.Flags().BoolVar(&compressValues, "compress-storage", false, `Gzip-compress objects written to etcd (default false)`)
+	rootCmd.Flags().StringVar(&errorVerbosity, "error-verbosity", string(api.ErrorVerbosityProduction), `Whether clients see the details of server errors, "debug", or only a generic message, "production"; details are always logged (default "production")`)
+	rootCmd.Flags().BoolVar(&skipSelfTest, "skip-storage-self-test", false, `Start without first checking that storage can create, read, update and delete a key under `+storage.SelfTestPrefix+` (default false)`)
+	rootCmd.Flags().BoolVar(&strictTypeMeta, "strict-type-meta", false, `Reject node bodies that aren't apiVersion v1, kind Node instead of defaulting them (default false)`)
+	rootCmd.Flags().BoolVar(&strictQuery, "strict-query-params", false, `Reject node requests with unknown query parameters instead of ignoring them (default false)`)
+	rootCmd.Flags().BoolVar(&readOnly, "read-only", false, `Start with writes disabled; operators toggle it at runtime with PUT /admin/readonly, so this requires --client-ca-file (default false)`)
+	rootCmd.Flags().IntVar(&maxPageBytes, "max-list-page-bytes", 0, `End node list pages before their encoded size exceeds this many bytes, 0 disables the cap (default 0)`)
+	rootCmd.Flags().BoolVar(&bootstrapAuth, "enable-bootstrap-token-auth", false, `Let nodes register themselves with bootstrap tokens that operators create with POST /admin/bootstrap-tokens (default false)`)
+	rootCmd.Flags().DurationVar(&compactionInterval, "compaction-interval", 0, `How often to compact etcd history, 0 disables compaction (default 0)`)
+	rootCmd.Flags().IntVar(&compactionKeep, "compaction-keep-revisions", 1000, `The number of recent revisions compaction keeps (default 1000)`)
+	rootCmd.Flags().DurationVar(&compactionKeepFor, "compaction-keep-for", 0, `Also keep every revision written within this long, 0 keeps by count only (default 0)`)
+	rootCmd.Flags().BoolVar(&compactionClusterWide, "enable-cluster-wide-compaction", false, `Allow compaction, which discards the history of every key in etcd, including other servers' (default false)`)
+	rootCmd.Flags().StringVar(&eventLogPath, "event-log", "", `File to record every node mutation in for replay, empty disables the log`)
+	rootCmd.Flags().DurationVar(&eventTTL, "event-ttl", 0, `How long the event log keeps an event after a later one replaced it, 0 keeps them forever (default 0)`)
+	rootCmd.Flags().DurationVar(&tombstoneTTL, "tombstone-ttl", 0, `How long the event log keeps a deleted node's events, 0 keeps them forever (default 0)`)
+	rootCmd.Flags().DurationVar(&purgeInterval, "event-log-purge-interval", 10*time.Minute, `How often to purge events past --event-ttl or --tombstone-ttl, 0 disables purging (default 10m)`)
+	rootCmd.Flags().StringToStringVar(&defaultNodeLabels, "default-node-labels", nil, `Labels to set on registered nodes that don't have them, as key=value pairs`)
+	rootCmd.Flags().StringSliceVar(&cordonedNodeRoles, "cordoned-node-roles", []string{api.NodeRoleControlPlane}, `Roles, from `+api.LabelNodeRolePrefix+`<role> labels, whose nodes are registered cordoned; empty registers every node schedulable`)
+	rootCmd.Flags().StringSliceVar(&requiredLabels, "required-node-labels", nil, `Labels every node must have, enforced by the RequiredLabels admission plugin`)
+	rootCmd.Flags().StringSliceVar(&admissionPlugins, "admission-plugins", []string{registry.RequiredLabelsPlugin}, `The admission plugins to run, in order; mutating plugins must come before validating ones`)
+	rootCmd.Flags().BoolVar(&normalizeNodeNames, "normalize-node-names", false, `Store node names lowercase without trailing dots (default false)`)
+	rootCmd.Flags().DurationVar(&watchCoalesce, "watch-coalesce-window", 0, `Collapse Modified events for a node within this window per watcher, 0 disables coalescing (default 0)`)
+	rootCmd.Flags().DurationVar(&watchHeartbeat, "watch-heartbeat", 30*time.Second, `How often to send a Bookmark on idle watches so proxies keep them open, 0 disables heartbeats (default 30s)`)
+	rootCmd.Flags().DurationVar(&streamIdleTimeout, "stream-idle-timeout", 0, `How long a single write to a watch or stream may block, 0 means no limit (default 0)`)
+	rootCmd.Flags().StringVar(&notifyURL, "notify-url", "", `Webhook to POST changes of annotated nodes to, empty disables notifications`)
+	rootCmd.Flags().StringVar(&notifyAnnotation, "notify-annotation", "notify=true", `The key=value annotation that selects nodes to notify about`)
+
+	rootCmd.Flags().BoolVar(&metricsNodeLabel, "metrics-node-label", false, `Label request metrics with the node name; creates a series per node (default false)`)
+	rootCmd.Flags().BoolVar(&metricsLatency, "metrics-latency-histogram", false, `Record a request latency histogram per verb (default false)`)
 
 	if err := rootCmd.Execute(); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -44,6 +120,26 @@ func main() {
 }
 
 func runAPIServer() error {
+	verbosity, err := api.ParseErrorVerbosity(errorVerbosity)
+	if err != nil {
+		return err
+	}
+	if compactionInterval > 0 && !compactionClusterWide {
+		return fmt.Errorf("--compaction-interval compacts all of etcd, not just this server's keys; pass --enable-cluster-wide-compaction to confirm")
+	}
+	var tlsConfig *tls.Config
+	if tlsCertFile != "" || tlsKeyFile != "" {
+		tlsConfig, err = server.LoadTLSConfig(tlsCertFile, tlsKeyFile, clientCAFile)
+		if err != nil {
+			return err
+		}
+	} else if clientCAFile != "" {
+		return fmt.Errorf("--client-ca-file requires --tls-cert-file and --tls-private-key-file")
+	}
+	if readOnly && !(server.ServerConfig{TLSConfig: tlsConfig}).VerifiesClientCertificates() {
+		return fmt.Errorf("--read-only could never be switched off, since operators can only authenticate with --client-ca-file")
+	}
+
 	// Create a channel to handle shutdown signals
 	stopCh := make(chan os.Signal, 1)
 	signal.Notify(stopCh, os.Interrupt, syscall.SIGTERM)
@@ -53,6 +149,9 @@ func runAPIServer() error {
 	if err != nil {
 		return fmt.Errorf("failed to start etcd: %v", err)
 	}
+	// Deferred first so it runs last, after everything that still uses etcd, and on
+	// every return, including a failed self-test
+	defer storage.StopEmbeddedEtcd(etcdServer)
 
 	cli, err := clientv3.New(clientv3.Config{
 		Endpoints:   []string{fmt.Sprintf("http://localhost:%d", port)},
@@ -63,25 +162,105 @@ func runAPIServer() error {
 	}
 	defer cli.Close()
 
-	store := storage.NewEtcdStorage(cli)
-	apiServer := server.NewAPIServer(store)
+	var store storage.Storage = storage.NewEtcdStorage(cli)
+	if compressValues {
+		store = storage.NewCompressedStorage(store)
+	}
+	if !skipSelfTest {
+		selfTestCtx, cancelSelfTest := context.WithTimeout(context.Background(), 10*time.Second)
+		err := storage.SelfTest(selfTestCtx, store)
+		cancelSelfTest()
+		if err != nil {
+			return fmt.Errorf("storage is not usable, check the etcd configuration: %w", err)
+		}
+	}
+
+	// ctx stops the background loops once the server has shut down
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if compactionInterval > 0 {
+		policy := storage.CompactionPolicy{KeepRevisions: compactionKeep, KeepFor: compactionKeepFor}
+		go storage.RunCompactor(ctx, store, compactionInterval, policy)
+	}
+
+	plugins := registry.NewAdmissionPlugins()
+	plugins.Register(registry.RequiredLabelsPlugin, registry.NewRequiredLabelsAdmission(requiredLabels...), false)
+	admission, err := plugins.Enabled(admissionPlugins)
+	if err != nil {
+		return err
+	}
+
+	registryOpts := []registry.Option{
+		registry.WithDefaultLabels(defaultNodeLabels),
+		registry.WithCordonedRoles(cordonedNodeRoles...),
+		admission,
+		registry.WithValidationObserver(metrics.NewValidationMetrics(prometheus.DefaultRegisterer).Observe),
+	}
+	if watchCoalesce > 0 {
+		registryOpts = append(registryOpts, registry.WithWatchCoalescing(watchCoalesce))
+	}
+	if normalizeNodeNames {
+		registryOpts = append(registryOpts, registry.WithNameNormalization())
+	}
+	if eventLogPath != "" {
+		eventLog, err := registry.OpenFileEventLog(eventLogPath)
+		if err != nil {
+			return err
+		}
+		defer eventLog.Close()
+		registryOpts = append(registryOpts, registry.WithEventLog(eventLog))
+
+		if purgeInterval > 0 && (eventTTL > 0 || tombstoneTTL > 0) {
+			policy := registry.RetentionPolicy{EventTTL: eventTTL, TombstoneTTL: tombstoneTTL}
+			observe := metrics.NewRetentionMetrics(prometheus.DefaultRegisterer).Observe
+			go registry.RunEventLogPurger(ctx, eventLog, purgeInterval, policy, clock.RealClock{}, observe)
+		}
+	}
+	nodeRegistry := registry.NewNodeRegistry(store, registryOpts...)
+	var bootstrapTokens server.BootstrapTokenStore
+	if bootstrapAuth {
+		bootstrapTokens = registry.NewBootstrapTokenRegistry(store, clock.RealClock{})
+	}
+	apiServer := server.NewServer(server.ServerConfig{
+		Addr:              address,
+		TLSConfig:         tlsConfig,
+		StrictTypeMeta:    strictTypeMeta,
+		StrictQueryParams: strictQuery,
+		ReadOnly:          api.NewReadOnlyMode(readOnly),
+		MaxPageBytes:      maxPageBytes,
+		WatchHeartbeat:    watchHeartbeat,
+		StreamIdleTimeout: streamIdleTimeout,
+		BootstrapTokens:   bootstrapTokens,
+		ErrorVerbosity:    verbosity,
+		RequestMetrics: metrics.NewRequestMetrics(prometheus.DefaultRegisterer, metrics.RequestMetricsConfig{
+			NodeNameLabel:     metricsNodeLabel,
+			DurationHistogram: metricsLatency,
+		}),
+	}, nodeRegistry)
+	go metrics.NewNodeMetrics(prometheus.DefaultRegisterer).Run(ctx, nodeRegistry)
+	if notifyURL != "" {
+		key, value, _ := strings.Cut(notifyAnnotation, "=")
+		notifier := notify.NewNotifier(notify.Config{URL: notifyURL, Annotation: key, AnnotationValue: value})
+		go notifier.Run(ctx, nodeRegistry)
+	}
 
 	fmt.Printf("Starting API server on %s\n", address)
 
 	// Start the API server in a goroutine
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- apiServer.Start(address)
+		errCh <- apiServer.Start()
 	}()
 
 	// Wait for either an error or shutdown signal
 	select {
 	case err := <-errCh:
-		storage.StopEmbeddedEtcd(etcdServer)
 		return err
 	case <-stopCh:
 		fmt.Println("\nReceived shutdown signal. Stopping services...")
-		storage.StopEmbeddedEtcd(etcdServer)
+		if err := apiServer.Stop(); err != nil {
+			fmt.Printf("Error stopping API server: %v\n", err)
+		}
 		return nil
 	}
 }
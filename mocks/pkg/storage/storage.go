@@ -41,6 +41,20 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// Compact mocks base method.
+func (m *MockStorage) Compact(ctx context.Context, keepRevisions int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compact", ctx, keepRevisions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Compact indicates an expected call of Compact.
+func (mr *MockStorageMockRecorder) Compact(ctx, keepRevisions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compact", reflect.TypeOf((*MockStorage)(nil).Compact), ctx, keepRevisions)
+}
+
 // Create mocks base method.
 func (m *MockStorage) Create(ctx context.Context, key string, obj runtime.Object) error {
 	m.ctrl.T.Helper()
@@ -83,6 +97,21 @@ func (mr *MockStorageMockRecorder) DeletePrefix(ctx, prefix any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrefix", reflect.TypeOf((*MockStorage)(nil).DeletePrefix), ctx, prefix)
 }
 
+// Exists mocks base method.
+func (m *MockStorage) Exists(ctx context.Context, key string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exists", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exists indicates an expected call of Exists.
+func (mr *MockStorageMockRecorder) Exists(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockStorage)(nil).Exists), ctx, key)
+}
+
 // Get mocks base method.
 func (m *MockStorage) Get(ctx context.Context, key string, obj runtime.Object) error {
 	m.ctrl.T.Helper()
@@ -97,6 +126,35 @@ func (mr *MockStorageMockRecorder) Get(ctx, key, obj any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStorage)(nil).Get), ctx, key, obj)
 }
 
+// GetAndDelete mocks base method.
+func (m *MockStorage) GetAndDelete(ctx context.Context, key string, obj runtime.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAndDelete", ctx, key, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAndDelete indicates an expected call of GetAndDelete.
+func (mr *MockStorageMockRecorder) GetAndDelete(ctx, key, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAndDelete", reflect.TypeOf((*MockStorage)(nil).GetAndDelete), ctx, key, obj)
+}
+
+// GetWithRevision mocks base method.
+func (m *MockStorage) GetWithRevision(ctx context.Context, key string, obj runtime.Object) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithRevision", ctx, key, obj)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithRevision indicates an expected call of GetWithRevision.
+func (mr *MockStorageMockRecorder) GetWithRevision(ctx, key, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithRevision", reflect.TypeOf((*MockStorage)(nil).GetWithRevision), ctx, key, obj)
+}
+
 // List mocks base method.
 func (m *MockStorage) List(ctx context.Context, prefix string, listObj any) error {
 	m.ctrl.T.Helper()
@@ -111,6 +169,35 @@ func (mr *MockStorageMockRecorder) List(ctx, prefix, listObj any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStorage)(nil).List), ctx, prefix, listObj)
 }
 
+// Revision mocks base method.
+func (m *MockStorage) Revision(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revision", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revision indicates an expected call of Revision.
+func (mr *MockStorageMockRecorder) Revision(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revision", reflect.TypeOf((*MockStorage)(nil).Revision), ctx)
+}
+
+// Scan mocks base method.
+func (m *MockStorage) Scan(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(string, runtime.Object, error) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", ctx, prefix, newObj, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockStorageMockRecorder) Scan(ctx, prefix, newObj, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockStorage)(nil).Scan), ctx, prefix, newObj, fn)
+}
+
 // Update mocks base method.
 func (m *MockStorage) Update(ctx context.Context, key string, obj runtime.Object) error {
 	m.ctrl.T.Helper()
@@ -124,3 +211,31 @@ func (mr *MockStorageMockRecorder) Update(ctx, key, obj any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStorage)(nil).Update), ctx, key, obj)
 }
+
+// UpdateIfRevision mocks base method.
+func (m *MockStorage) UpdateIfRevision(ctx context.Context, key string, obj runtime.Object, revision int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIfRevision", ctx, key, obj, revision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIfRevision indicates an expected call of UpdateIfRevision.
+func (mr *MockStorageMockRecorder) UpdateIfRevision(ctx, key, obj, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIfRevision", reflect.TypeOf((*MockStorage)(nil).UpdateIfRevision), ctx, key, obj, revision)
+}
+
+// Walk mocks base method.
+func (m *MockStorage) Walk(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(runtime.Object) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Walk", ctx, prefix, newObj, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Walk indicates an expected call of Walk.
+func (mr *MockStorageMockRecorder) Walk(ctx, prefix, newObj, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Walk", reflect.TypeOf((*MockStorage)(nil).Walk), ctx, prefix, newObj, fn)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// BootstrapNodesGroup must be among a bootstrap token's groups for it to register
+// nodes
+const BootstrapNodesGroup = "system:bootstrappers:nodes"
+
+// BootstrapTokenAnnotation records the ID of the bootstrap token a node registered
+// itself with
+const BootstrapTokenAnnotation = "gokube.io/bootstrap-token"
+
+// ErrUnauthorized is returned for requests with missing, unknown or expired
+// credentials
+var ErrUnauthorized = errors.New("unauthorized")
+
+// BootstrapToken is a short-lived shared credential that lets a new node register
+// itself before it has a client certificate. Nodes present it as the bearer token
+// "<id>.<secret>".
+type BootstrapToken struct {
+	ID         string    `json:"id"`
+	Secret     string    `json:"secret"`
+	Expiration time.Time `json:"expiration"`
+	// NodeName is the only Node the token may create, and only while that Node
+	// doesn't exist yet
+	NodeName string `json:"nodeName"`
+	// Groups the token authenticates as, see BootstrapNodesGroup
+	Groups []string `json:"groups,omitempty"`
+}
+
+// TokenAuthenticator looks up the bootstrap token a request presented
+type TokenAuthenticator interface {
+	// AuthenticateToken returns the token matching "<id>.<secret>", or an error
+	// wrapping ErrUnauthorized if there is none or it has expired
+	AuthenticateToken(ctx context.Context, token string) (*BootstrapToken, error)
+}
+
+type bootstrapTokenKey struct{}
+
+// BootstrapTokenFor returns the bootstrap token r was authenticated with, if any
+func BootstrapTokenFor(r *http.Request) (*BootstrapToken, bool) {
+	token, ok := r.Context().Value(bootstrapTokenKey{}).(*BootstrapToken)
+	return token, ok
+}
+
+// BootstrapTokenFilter authenticates requests that carry a bearer token with auth.
+// Invalid and expired tokens are rejected with 401. A valid token may only be used
+// to create a node, anything else is rejected with 403; which node it may create
+// is checked once the body is decoded, see BootstrapToken.NodeName. Requests
+// without a bearer token are passed on unchanged.
+func BootstrapTokenFilter(auth TokenAuthenticator) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		bearer, ok := strings.CutPrefix(req.HeaderParameter("Authorization"), "Bearer ")
+		if !ok {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		token, err := auth.AuthenticateToken(req.Request.Context(), bearer)
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			WriteError(req, resp, http.StatusUnauthorized, err)
+			return
+		case err != nil:
+			WriteError(req, resp, http.StatusInternalServerError, err)
+			return
+		}
+
+		registering := req.Request.Method == http.MethodPost && strings.HasSuffix(req.SelectedRoutePath(), "/nodes")
+		if !registering || !slices.Contains(token.Groups, BootstrapNodesGroup) {
+			WriteError(req, resp, http.StatusForbidden, fmt.Errorf("bootstrap token %s may only be used to register a node", token.ID))
+			return
+		}
+
+		req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), bootstrapTokenKey{}, token))
+		chain.ProcessFilter(req, resp)
+	}
+}
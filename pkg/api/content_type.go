@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// ContentTypeFilter returns a container filter that rejects POST, PUT and PATCH
+// requests with a body whose Content-Type isn't one of allowed with 415, before any
+// route gets to parse the body. Media type parameters such as charset are ignored.
+func ContentTypeFilter(allowed ...string) restful.FilterFunction {
+	allowedTypes := make(map[string]bool, len(allowed))
+	for _, contentType := range allowed {
+		allowedTypes[strings.ToLower(contentType)] = true
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		switch req.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			chain.ProcessFilter(req, resp)
+			return
+		}
+		if req.Request.ContentLength == 0 {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		header := req.Request.Header.Get("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err != nil || !allowedTypes[mediaType] {
+			WriteError(req, resp, http.StatusUnsupportedMediaType,
+				fmt.Errorf("content type %q is not supported, use one of: %s", header, strings.Join(allowed, ", ")))
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
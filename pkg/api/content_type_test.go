@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentTypeFilter(t *testing.T) {
+	container := restful.NewContainer()
+	container.Filter(ContentTypeFilter(restful.MIME_JSON))
+	ws := new(restful.WebService)
+	ws.Path("/api/v1").Produces(restful.MIME_JSON)
+	echo := func(request *restful.Request, response *restful.Response) {
+		WriteResponse(response, http.StatusOK, nil)
+	}
+	ws.Route(ws.GET("/nodes").To(echo))
+	ws.Route(ws.POST("/nodes").To(echo))
+	container.Add(ws)
+
+	serve := func(method, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/nodes", strings.NewReader("name: test-node"))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject a write with a content type that isn't allowed", func(t *testing.T) {
+		resp := serve("POST", "text/plain")
+
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+		var status Status
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		assert.Equal(t, StatusReasonUnsupportedMediaType, status.Reason)
+		assert.Contains(t, status.Message, "text/plain")
+	})
+
+	t.Run("should reject a write without a content type", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, serve("POST", "").Code)
+	})
+
+	t.Run("should accept an allowed content type with parameters", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("POST", "application/json; charset=utf-8").Code)
+	})
+
+	t.Run("should not check reads", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("GET", "text/plain").Code)
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// ErrorVerbosity says how much of a server error is shown to clients
+type ErrorVerbosity string
+
+const (
+	// ErrorVerbosityDebug shows clients the full error chain of every error
+	ErrorVerbosityDebug ErrorVerbosity = "debug"
+	// ErrorVerbosityProduction replaces the message of 5xx errors, which may carry
+	// storage or other internal details, with a generic one. Client errors are
+	// shown in full, since they describe the request.
+	ErrorVerbosityProduction ErrorVerbosity = "production"
+)
+
+const errorVerbosityAttribute = "gokube.errorVerbosity"
+
+// ParseErrorVerbosity parses "debug" or "production"
+func ParseErrorVerbosity(s string) (ErrorVerbosity, error) {
+	switch v := ErrorVerbosity(s); v {
+	case ErrorVerbosityDebug, ErrorVerbosityProduction:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown error verbosity %q, must be %q or %q", s, ErrorVerbosityDebug, ErrorVerbosityProduction)
+	}
+}
+
+// ErrorVerbosityFilter makes errors written for the requests it filters as verbose
+// as verbosity; without it errors are shown in full
+func ErrorVerbosityFilter(verbosity ErrorVerbosity) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		req.SetAttribute(errorVerbosityAttribute, verbosity)
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// errorMessage returns the message to show the client for err. Server errors are
+// always logged in full, with the request ID so the log line can be found from
+// the response.
+func errorMessage(request *restful.Request, response *restful.Response, status int, err error) string {
+	if err == nil {
+		return ""
+	}
+	if status < http.StatusInternalServerError || request == nil || request.Request == nil {
+		return err.Error()
+	}
+
+	requestID := response.Header().Get(HeaderRequestID)
+	log.Printf("Error serving %s %s: %v request_id=%s", request.Request.Method, request.Request.URL.RequestURI(), err, requestID)
+
+	if verbosity, _ := request.Attribute(errorVerbosityAttribute).(ErrorVerbosity); verbosity != ErrorVerbosityProduction {
+		return err.Error()
+	}
+	if requestID == "" {
+		return "internal error"
+	}
+	return fmt.Sprintf("internal error, see the server log for request %s", requestID)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorVerbosity(t *testing.T) {
+	errStorage := errors.New("etcd client error: dial tcp 10.0.0.7:2379: connection refused")
+	newContainer := func(verbosity ErrorVerbosity, status int) *restful.Container {
+		container := restful.NewContainer()
+		container.Filter(RequestIDFilter)
+		container.Filter(ErrorVerbosityFilter(verbosity))
+
+		ws := new(restful.WebService)
+		ws.Path("/api/v1").Produces(restful.MIME_JSON)
+		ws.Route(ws.GET("/nodes/{name}").To(func(request *restful.Request, response *restful.Response) {
+			WriteError(request, response, status, fmt.Errorf("failed to get node: %w", errStorage))
+		}))
+		container.Add(ws)
+		return container
+	}
+	get := func(container *restful.Container) Status {
+		req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+		req.Header.Set(HeaderRequestID, "req-123")
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+
+		var status Status
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		return status
+	}
+
+	t.Run("should hide server error details in production but log them", func(t *testing.T) {
+		var logs bytes.Buffer
+		log.SetOutput(&logs)
+		defer log.SetOutput(os.Stderr)
+
+		status := get(newContainer(ErrorVerbosityProduction, http.StatusInternalServerError))
+
+		assert.Equal(t, "internal error, see the server log for request req-123", status.Message)
+		assert.Equal(t, StatusReasonInternalError, status.Reason)
+		assert.Contains(t, logs.String(), "connection refused request_id=req-123")
+	})
+
+	t.Run("should show server error details in debug mode", func(t *testing.T) {
+		status := get(newContainer(ErrorVerbosityDebug, http.StatusInternalServerError))
+
+		assert.Equal(t, "failed to get node: "+errStorage.Error(), status.Message)
+	})
+
+	t.Run("should show client errors in production", func(t *testing.T) {
+		status := get(newContainer(ErrorVerbosityProduction, http.StatusBadRequest))
+
+		assert.Equal(t, "failed to get node: "+errStorage.Error(), status.Message)
+	})
+}
+
+func TestParseErrorVerbosity(t *testing.T) {
+	verbosity, err := ParseErrorVerbosity("production")
+	require.NoError(t, err)
+	assert.Equal(t, ErrorVerbosityProduction, verbosity)
+
+	_, err = ParseErrorVerbosity("verbose")
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	v1 "gokube/pkg/api/v1"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// strictDecodingAttribute marks requests whose body must not contain unknown fields
+const strictDecodingAttribute = "gokube.strictDecoding"
+
+// ErrUnknownFields is returned by strict decoding for bodies with fields the target
+// type doesn't have
+var ErrUnknownFields = errors.New("unknown fields")
+
+// ErrUnsupportedType is returned in strict mode for bodies that aren't a v1 Node
+var ErrUnsupportedType = errors.New("unsupported apiVersion or kind")
+
+// strictDecodingFilter enables strict decoding for the route it is attached to
+func strictDecodingFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	req.SetAttribute(strictDecodingAttribute, true)
+	chain.ProcessFilter(req, resp)
+}
+
+// readEntity decodes the request body into entity, turning JSON decoding failures
+// into an ErrNodeInvalid error that says what was wrong and where. On routes with
+// strict decoding, fields entity doesn't have are rejected with ErrUnknownFields.
+func readEntity(request *restful.Request, entity interface{}) error {
+	if strict, _ := request.Attribute(strictDecodingAttribute).(bool); strict {
+		return readEntityStrict(request, entity)
+	}
+
+	if err := request.ReadEntity(entity); err != nil {
+		return fmt.Errorf("%w: %s", registry.ErrNodeInvalid, describeDecodeError(err))
+	}
+	return nil
+}
+
+func readEntityStrict(request *restful.Request, entity interface{}) error {
+	data, err := io.ReadAll(request.Request.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", registry.ErrNodeInvalid, err)
+	}
+	return decodeStrict(data, entity)
+}
+
+// decodeEntity decodes a JSON body that was already read, such as an applied
+// configuration, into entity the way readEntity would
+func decodeEntity(request *restful.Request, data []byte, entity interface{}) error {
+	if strict, _ := request.Attribute(strictDecodingAttribute).(bool); strict {
+		return decodeStrict(data, entity)
+	}
+	if err := json.Unmarshal(data, entity); err != nil {
+		return fmt.Errorf("%w: %s", registry.ErrNodeInvalid, describeDecodeError(err))
+	}
+	return nil
+}
+
+func decodeStrict(data []byte, entity interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(entity)
+	if err == nil {
+		return nil
+	}
+	// The decoder stops at the first unknown field, so look for the others to report
+	// them all at once
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		unknown := unknownFields(data, reflect.TypeOf(entity), "")
+		if len(unknown) == 0 {
+			unknown = []string{strings.Trim(field, `"`)}
+		}
+		return fmt.Errorf("%w: %s", ErrUnknownFields, strings.Join(unknown, ", "))
+	}
+	return fmt.Errorf("%w: %s", registry.ErrNodeInvalid, describeDecodeError(err))
+}
+
+// checkTypeMeta makes sure node is a v1 Node. In strict mode a missing or different
+// apiVersion or kind is an ErrUnsupportedType error, otherwise both are defaulted.
+func (h *NodeHandler) checkTypeMeta(node *v1.Node) error {
+	if !h.strictTypeMeta {
+		node.APIVersion = v1.Version
+		node.Kind = v1.KindNode
+		return nil
+	}
+
+	if node.Kind != v1.KindNode {
+		return fmt.Errorf("%w: kind is %q, expected %q", ErrUnsupportedType, node.Kind, v1.KindNode)
+	}
+	if node.APIVersion != v1.Version {
+		return fmt.Errorf("%w: apiVersion is %q, expected %q", ErrUnsupportedType, node.APIVersion, v1.Version)
+	}
+	return nil
+}
+
+// decodeStatusCode returns the HTTP status code for an error returned by readEntity
+// or checkTypeMeta
+func decodeStatusCode(err error) int {
+	if errors.Is(err, ErrUnknownFields) || errors.Is(err, ErrUnsupportedType) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// describeDecodeError explains a JSON decoding error in terms of the request body
+func describeDecodeError(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("malformed JSON at offset %d: %v", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Sprintf("cannot unmarshal %s into field %s of type %s", typeErr.Value, typeErr.Field, typeErr.Type)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("cannot unmarshal %s into %s", typeErr.Value, typeErr.Type)
+	case errors.Is(err, io.EOF):
+		return "request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "request body ends in the middle of a JSON value"
+	default:
+		return err.Error()
+	}
+}
+
+// unknownFields returns the paths of the keys of the JSON object data, and of the
+// objects nested in it, that don't match a JSON tag of the struct t
+func unknownFields(data json.RawMessage, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var object map[string]json.RawMessage
+	if t.Kind() != reflect.Struct || json.Unmarshal(data, &object) != nil {
+		return nil
+	}
+
+	fields := jsonFields(t)
+	var unknown []string
+	for _, key := range slices.Sorted(maps.Keys(object)) {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		fieldType, ok := fields[strings.ToLower(key)]
+		if !ok {
+			unknown = append(unknown, keyPath)
+			continue
+		}
+		unknown = append(unknown, unknownFields(object[key], fieldType, keyPath)...)
+	}
+	return unknown
+}
+
+// jsonFields maps the lowercased JSON names of t's fields to their types, including
+// those of untagged embedded structs; encoding/json matches names case-insensitively
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+		case field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct:
+			maps.Copy(fields, jsonFields(field.Type))
+		case !field.IsExported():
+		case name == "":
+			fields[strings.ToLower(field.Name)] = field.Type
+		default:
+			fields[strings.ToLower(name)] = field.Type
+		}
+	}
+	return fields
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	body := `{"metadata": {"name": "test-node", "lables": {"zone": "a"}}, "spec": {"unschedulable": true, "taints": []}}`
+
+	t.Run("should reject unknown fields in strict mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler, WithStrictDecoding("POST /nodes"))
+
+			req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+			var status api.Status
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+			assert.Equal(t, api.StatusReasonInvalid, status.Reason)
+			assert.Contains(t, status.Message, "metadata.lables, spec.taints")
+		})
+	})
+
+	t.Run("should ignore unknown fields in lenient mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusCreated, resp.Code)
+		})
+	})
+}
+
+func TestStrictTypeMeta(t *testing.T) {
+	create := func(container *restful.Container, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(body))
+		req.Header.Set("Content-Type", restful.MIME_JSON)
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject other kinds in strict mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)), WithStrictTypeMeta())
+			RegisterNodeRoutes(ws, handler)
+
+			resp := create(container, `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test-node"}}`)
+			require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+			var status api.Status
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+			assert.Contains(t, status.Message, `kind is "Pod"`)
+
+			resp = create(container, `{"apiVersion": "v2", "kind": "Node", "metadata": {"name": "test-node"}}`)
+			assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+			resp = create(container, `{"metadata": {"name": "test-node"}}`)
+			assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+			resp = create(container, `{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "test-node"}}`)
+			assert.Equal(t, http.StatusCreated, resp.Code)
+		})
+	})
+
+	t.Run("should default apiVersion and kind in lenient mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			resp := create(container, `{"metadata": {"name": "test-node"}}`)
+			require.Equal(t, http.StatusCreated, resp.Code)
+
+			var node map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &node))
+			assert.Equal(t, "v1", node["apiVersion"])
+			assert.Equal(t, "Node", node["kind"])
+		})
+	})
+}
+
+func TestUnknownFields(t *testing.T) {
+	data := []byte(`{"metadata": {"Name": "n", "labels": {"any": "key"}, "extra": 1}, "kind": "Node", "name": "n", "status": "Ready", "bogus": [1]}`)
+
+	assert.Equal(t, []string{"bogus", "metadata.extra", "name"}, unknownFields(data, reflect.TypeOf(&v1.Node{}), ""))
+}
@@ -1,103 +1,633 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+	"gokube/pkg/labels"
 	"gokube/pkg/registry"
 
 	"github.com/emicklei/go-restful/v3"
 )
 
+const (
+	// HeaderTotalCount is the number of Nodes that exist, regardless of filtering
+	HeaderTotalCount = "X-Total-Count"
+	// HeaderFilteredCount is the number of Nodes that matched the list filters
+	HeaderFilteredCount = "X-Filtered-Count"
+	// HeaderContinue carries the token for the next page of a list
+	HeaderContinue = "X-Continue"
+	// HeaderWarning carries non-fatal warnings about how a request was handled
+	HeaderWarning = "Warning"
+	// HeaderResourceVersion is the registry revision a list was read at, to pass as
+	// ?resourceVersion= when long-polling with ?wait=
+	HeaderResourceVersion = "X-Resource-Version"
+
+	DefaultPageSize = 500
+	MaxPageSize     = 1000
+)
+
 // NodeHandler handles Node-related HTTP requests
 type NodeHandler struct {
-	nodeRegistry *registry.NodeRegistry
+	nodeRegistry registry.NodeInterface
+
+	defaultPageSize int
+	maxPageSize     int
+	// maxPageBytes caps the encoded size of a list page when non-zero
+	maxPageBytes int
+	// watchHeartbeat is how often idle watches get a Bookmark, 0 disables them
+	watchHeartbeat    time.Duration
+	streamIdleTimeout time.Duration
+	// strictTypeMeta rejects bodies that aren't a v1 Node instead of defaulting them
+	strictTypeMeta bool
+}
+
+// HandlerOption configures optional NodeHandler behaviour
+type HandlerOption func(*NodeHandler)
+
+// WithPageSize sets the page size used when a list doesn't ask for one and the
+// largest page size a list may ask for
+func WithPageSize(defaultSize, maxSize int) HandlerOption {
+	return func(h *NodeHandler) {
+		h.defaultPageSize = defaultSize
+		h.maxPageSize = maxSize
+	}
+}
+
+// WithMaxPageBytes caps the encoded size of a list page. Once the next Node would
+// take a page past maxBytes the page ends there, with a continue token and a
+// Warning header, whatever limit was asked for.
+func WithMaxPageBytes(maxBytes int) HandlerOption {
+	return func(h *NodeHandler) {
+		h.maxPageBytes = maxBytes
+	}
+}
+
+// WithStreamTimeouts tunes long-lived streams such as watches. Every heartbeat, each
+// watch is sent a Bookmark event, so that proxies in between see traffic and don't
+// close the connection as idle; 0 sends none. idleTimeout limits how long a single
+// write to a stream may block before the stream is given up; 0 means writes never
+// time out. Either way the server's WriteTimeout no longer applies to streams.
+func WithStreamTimeouts(heartbeat, idleTimeout time.Duration) HandlerOption {
+	return func(h *NodeHandler) {
+		h.watchHeartbeat = heartbeat
+		h.streamIdleTimeout = idleTimeout
+	}
+}
+
+// WithStrictTypeMeta makes the handler reject Node bodies whose apiVersion isn't
+// v1 or whose kind isn't Node, including bodies that leave them out. Without it
+// both are set to v1 Node.
+func WithStrictTypeMeta() HandlerOption {
+	return func(h *NodeHandler) {
+		h.strictTypeMeta = true
+	}
 }
 
 // NewNodeHandler creates a new NodeHandler
-func NewNodeHandler(nodeRegistry *registry.NodeRegistry) *NodeHandler {
-	return &NodeHandler{nodeRegistry: nodeRegistry}
+func NewNodeHandler(nodeRegistry registry.NodeInterface, opts ...HandlerOption) *NodeHandler {
+	h := &NodeHandler{
+		nodeRegistry:    nodeRegistry,
+		defaultPageSize: DefaultPageSize,
+		maxPageSize:     MaxPageSize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.defaultPageSize > h.maxPageSize {
+		h.defaultPageSize = h.maxPageSize
+	}
+	return h
 }
 
-// CreateNode handles POST requests to create a new Node
+// CreateNode handles POST requests to create a new Node, pointing the Location
+// header at the created Node. With If-None-Match: * creating a Node that already
+// exists is not an error; the existing Node is returned with 200 and left unchanged.
 func (h *NodeHandler) CreateNode(request *restful.Request, response *restful.Response) {
-	node := &api.Node{}
-	if err := request.ReadEntity(node); err != nil {
-		api.WriteError(response, http.StatusBadRequest, err)
+	external := &v1.Node{}
+	if err := readEntity(request, external); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+	if err := h.checkTypeMeta(external); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
 		return
 	}
 
-	err := h.nodeRegistry.CreateNode(request.Request.Context(), node)
-	h.handleNodeResponse(response, http.StatusCreated, node, err)
+	ctx := request.Request.Context()
+	node := v1.ConvertToInternal(external)
+	if identity, ok := api.NodeIdentity(request.Request); ok && node.Name != identity {
+		api.WriteError(request, response, http.StatusForbidden, fmt.Errorf("%w: node %s may not create node %s", ErrForbidden, identity, node.Name))
+		return
+	}
+	// A node registering itself with a bootstrap token starts out cordoned and
+	// Pending until an administrator approves it by uncordoning it
+	token, bootstrapping := api.BootstrapTokenFor(request.Request)
+	if bootstrapping {
+		if node.Name != token.NodeName {
+			api.WriteError(request, response, http.StatusForbidden,
+				fmt.Errorf("%w: bootstrap token %s may only register node %s", ErrForbidden, token.ID, token.NodeName))
+			return
+		}
+		node.Status = ""
+		node.Spec.Unschedulable = true
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[api.BootstrapTokenAnnotation] = token.ID
+	}
+	err := h.nodeRegistry.CreateNode(ctx, node)
+	if errors.Is(err, registry.ErrNodeAlreadyExists) && bootstrapping {
+		// The token is spent once its node exists, it can't take over the Node
+		api.WriteError(request, response, http.StatusForbidden,
+			fmt.Errorf("%w: node %s is already registered, bootstrap token %s can't be used again", ErrForbidden, node.Name, token.ID))
+		return
+	}
+	if errors.Is(err, registry.ErrNodeAlreadyExists) && request.HeaderParameter("If-None-Match") == "*" {
+		existing, err := h.nodeRegistry.GetNode(ctx, node.Name)
+		h.handleNodeResponse(request, response, http.StatusOK, v1.ConvertFromInternal(existing), err)
+		return
+	}
+	if err == nil {
+		response.Header().Set("Location", path.Join(request.Request.URL.Path, node.Name))
+	}
+	h.handleNodeResponse(request, response, http.StatusCreated, v1.ConvertFromInternal(node), err)
 }
 
 // GetNode handles GET requests to retrieve a Node
 func (h *NodeHandler) GetNode(request *restful.Request, response *restful.Response) {
 	name := request.PathParameter("name")
 	node, err := h.nodeRegistry.GetNode(request.Request.Context(), name)
-	h.handleNodeResponse(response, http.StatusOK, node, err)
+	h.handleNodeResponse(request, response, http.StatusOK, v1.ConvertFromInternal(node), err)
+}
+
+// NodeExists handles HEAD requests, replying 200 or 404 without a body
+func (h *NodeHandler) NodeExists(request *restful.Request, response *restful.Response) {
+	exists, err := h.nodeRegistry.NodeExists(request.Request.Context(), request.PathParameter("name"))
+	switch {
+	case err != nil:
+		response.WriteHeader(registry.StatusCode(err))
+	case !exists:
+		response.WriteHeader(http.StatusNotFound)
+	default:
+		response.WriteHeader(http.StatusOK)
+	}
 }
 
-// UpdateNode handles PUT requests to update a Node
+// UpdateNode handles PUT requests to update a Node. With an If-Unmodified-Since
+// header the update fails with 412 if the Node changed after that time. With
+// merge=true the body is merged into the stored Node instead of replacing it.
+// A node updating itself only updates its status, see updateOwnStatus.
 func (h *NodeHandler) UpdateNode(request *restful.Request, response *restful.Response) {
 	name := request.PathParameter("name")
-	node := &api.Node{}
-	if err := request.ReadEntity(node); err != nil {
-		api.WriteError(response, http.StatusBadRequest, err)
+	merge, err := mergeParam(request)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, err)
+		return
+	}
+
+	// A merge needs to know which fields the body set, so keep it to decode again
+	var body []byte
+	if merge {
+		if body, err = io.ReadAll(request.Request.Body); err != nil {
+			api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: %v", registry.ErrNodeInvalid, err))
+			return
+		}
+		request.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	external := &v1.Node{}
+	if err := readEntity(request, external); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+	if err := h.checkTypeMeta(external); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+
+	if name != external.Name {
+		api.WriteError(request, response, http.StatusBadRequest, registry.ErrNodeInvalid)
+		return
+	}
+
+	if merge {
+		node, err := h.nodeRegistry.MergeNode(request.Request.Context(), name, body)
+		h.handleNodeResponse(request, response, http.StatusOK, v1.ConvertFromInternal(node), err)
 		return
 	}
 
-	if name != node.Name {
-		api.WriteError(response, http.StatusBadRequest, registry.ErrNodeInvalid)
+	node := v1.ConvertToInternal(external)
+	if _, ok := api.NodeIdentity(request.Request); ok {
+		h.updateOwnStatus(request, response, node)
 		return
 	}
+	if header := request.HeaderParameter("If-Unmodified-Since"); header != "" {
+		since, parseErr := http.ParseTime(header)
+		if parseErr != nil {
+			api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: invalid If-Unmodified-Since %q", registry.ErrNodeInvalid, header))
+			return
+		}
+		err = h.nodeRegistry.UpdateNodeIfUnmodifiedSince(request.Request.Context(), node, since)
+	} else {
+		err = h.nodeRegistry.UpdateNode(request.Request.Context(), node)
+	}
+	h.handleNodeResponse(request, response, http.StatusOK, v1.ConvertFromInternal(node), err)
+}
+
+// mergeParam parses ?merge= of a PUT. Since a plain PUT silently clears whatever
+// the body leaves out, anything ambiguous is rejected with an explanation rather
+// than taken as either behaviour.
+func mergeParam(request *restful.Request) (bool, error) {
+	const semantics = "a PUT replaces the whole node and clears fields the body leaves out, " +
+		"with merge=true fields the body leaves out are kept"
+
+	var merge bool
+	switch value := request.QueryParameter("merge"); value {
+	case "", "false":
+	case "true":
+		merge = true
+	default:
+		return false, fmt.Errorf("%w: merge must be true or false, not %q; %s", registry.ErrNodeInvalid, value, semantics)
+	}
+	if merge && request.HeaderParameter("If-Unmodified-Since") != "" {
+		return false, fmt.Errorf("%w: If-Unmodified-Since can't be combined with merge=true, "+
+			"a merge is always applied to the latest version; %s", registry.ErrNodeInvalid, semantics)
+	}
+	return merge, nil
+}
 
-	err := h.nodeRegistry.UpdateNode(request.Request.Context(), node)
-	h.handleNodeResponse(response, http.StatusOK, node, err)
+// GetPoolSummary handles GET requests for the aggregate state of a node pool
+func (h *NodeHandler) GetPoolSummary(request *restful.Request, response *restful.Response) {
+	summary, err := h.nodeRegistry.GetPoolSummary(request.Request.Context(), request.PathParameter("pool"))
+	h.handleNodeResponse(request, response, http.StatusOK, summary, err)
 }
 
 // handleNodeResponse processes the response for node operations, handling both success and error cases
-func (h *NodeHandler) handleNodeResponse(response *restful.Response, successStatus int, result interface{}, err error) {
+func (h *NodeHandler) handleNodeResponse(request *restful.Request, response *restful.Response, successStatus int, result interface{}, err error) {
 	if err != nil {
-		switch {
-		case errors.Is(err, registry.ErrNodeNotFound):
-			api.WriteError(response, http.StatusNotFound, err)
-		case errors.Is(err, registry.ErrNodeInvalid):
-			api.WriteError(response, http.StatusBadRequest, err)
-		case errors.Is(err, registry.ErrNodeAlreadyExists):
-			api.WriteError(response, http.StatusConflict, err)
-		case errors.Is(err, registry.ErrListNodesFailed):
-			api.WriteError(response, http.StatusInternalServerError, err)
-		case errors.Is(err, registry.ErrInternal):
-			api.WriteError(response, http.StatusInternalServerError, err)
-		default:
-			api.WriteError(response, http.StatusInternalServerError, err)
-		}
+		api.WriteErrorWithReason(request, response, registry.StatusCode(err), registry.Reason(err), err)
 		return
 	}
 
 	api.WriteResponse(response, successStatus, result)
 }
 
-// DeleteNode handles DELETE requests to remove a Node
+// DeleteNode handles DELETE requests to remove a Node. A node identified by its
+// client certificate may only delete itself, see nodeScopeFilter.
 func (h *NodeHandler) DeleteNode(request *restful.Request, response *restful.Response) {
 	name := request.PathParameter("name")
 	err := h.nodeRegistry.DeleteNode(request.Request.Context(), name)
-	h.handleNodeResponse(response, http.StatusNoContent, name, err)
+	h.handleNodeResponse(request, response, http.StatusNoContent, name, err)
 }
 
-// ListNodes handles GET requests to list all Nodes
+// ListNodes handles GET requests to list all Nodes, optionally only those in ?phase=,
+// matching ?labelSelector= and ?fieldSelector= and, with ?schedulable=true, Ready
+// and not cordoned; all given conditions must hold. The X-Total-Count and
+// X-Filtered-Count headers tell how many nodes exist and how many of them matched.
+// Results are paged: ?limit= sets the page size (0 or absent means the server
+// default, values above the server maximum are reduced with a Warning header) and
+// ?continue= takes the X-Continue token of the previous page.
+// With ?stream=ndjson the nodes are streamed one JSON object per line as they are
+// read from storage instead of being collected into a single array; counts are not
+// known up front, so the count headers are omitted.
+// With ?watch=true the response is instead a stream of watch events, preceded by the
+// current Nodes and a Bookmark if ?sendInitialEvents=true, and limited to the event
+// types in ?eventTypes=, e.g. Deleted,Added, if that is set.
+// With ?wait= the list is long-polled: see waitForChange.
 func (h *NodeHandler) ListNodes(request *restful.Request, response *restful.Response) {
-	nodes, err := h.nodeRegistry.ListNodes(request.Request.Context())
-	h.handleNodeResponse(response, http.StatusOK, nodes, err)
+	filter, err := nodeFilterFromRequest(request)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.QueryParameter("watch") == "true" {
+		eventTypes, err := eventTypesParam(request)
+		if err != nil {
+			api.WriteError(request, response, http.StatusBadRequest, err)
+			return
+		}
+		h.watchNodes(request, response, filter, request.QueryParameter("sendInitialEvents") == "true", eventTypes)
+		return
+	}
+
+	switch stream := request.QueryParameter("stream"); stream {
+	case "":
+	case "ndjson":
+		h.streamNodes(request, response, filter)
+		return
+	default:
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: unsupported stream format %q", registry.ErrNodeInvalid, stream))
+		return
+	}
+
+	if request.QueryParameter("wait") != "" && !h.waitForChange(request, response) {
+		return
+	}
+
+	limit, err := h.pageLimit(request, response)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, err)
+		return
+	}
+
+	// Read the revision first, so a change made during the list is reported again
+	revision := h.nodeRegistry.Revision()
+	nodes, total, err := h.nodeRegistry.FilterNodes(request.Request.Context(), filter)
+	if err != nil {
+		h.handleNodeResponse(request, response, http.StatusOK, nil, err)
+		return
+	}
+
+	response.Header().Set(HeaderResourceVersion, strconv.FormatUint(revision, 10))
+	response.Header().Set(HeaderTotalCount, strconv.Itoa(total))
+	response.Header().Set(HeaderFilteredCount, strconv.Itoa(len(nodes)))
+	page, next := registry.PageNodes(nodes, request.QueryParameter("continue"), limit)
+	if acceptsTable(request) {
+		// Rows are a fraction of the size of a node, so maxPageBytes isn't applied
+		if next != "" {
+			response.Header().Set(HeaderContinue, next)
+		}
+		writeNodeTable(response, page, time.Now())
+		return
+	}
+	list := v1.ConvertListFromInternal(page)
+	if h.maxPageBytes > 0 {
+		n, err := fitPageBytes(list, h.maxPageBytes)
+		if err != nil {
+			api.WriteError(request, response, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		if n < len(list) {
+			list = list[:n]
+			next = page[n-1].Name
+			response.Header().Add(HeaderWarning,
+				fmt.Sprintf(`299 - "page limited to %d nodes by the %d byte response size limit"`, n, h.maxPageBytes))
+		}
+	}
+	if next != "" {
+		response.Header().Set(HeaderContinue, next)
+	}
+	h.handleNodeResponse(request, response, http.StatusOK, list, nil)
+}
+
+// fitPageBytes returns how many of the leading nodes fit into a compactly encoded
+// JSON array of at most maxBytes; pretty-printing the response adds whitespace on
+// top of that. A first node that doesn't fit on its own can never be listed and
+// is reported as an error.
+func fitPageBytes(nodes []*v1.Node, maxBytes int) (int, error) {
+	size := len("[]")
+	for i, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", registry.ErrInternal, err)
+		}
+		if i > 0 {
+			size += len(",")
+		}
+		size += len(data)
+		if size > maxBytes {
+			if i == 0 {
+				return 0, fmt.Errorf("node %s alone exceeds the %d byte response size limit", node.Name, maxBytes)
+			}
+			return i, nil
+		}
+	}
+	return len(nodes), nil
+}
+
+// waitForChange long-polls for ListNodes: it blocks for up to ?wait= until a Node
+// changes after the revision in ?resourceVersion=, or the current one if that is
+// absent. It reports whether the list should be sent; if not, it has already replied
+// with 304 Not Modified or an error.
+func (h *NodeHandler) waitForChange(request *restful.Request, response *restful.Response) bool {
+	waitParam := request.QueryParameter("wait")
+	wait, err := time.ParseDuration(waitParam)
+	if err != nil || wait <= 0 {
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: invalid wait %q", registry.ErrNodeInvalid, waitParam))
+		return false
+	}
+
+	since := h.nodeRegistry.Revision()
+	if rv := request.QueryParameter("resourceVersion"); rv != "" {
+		if since, err = strconv.ParseUint(rv, 10, 64); err != nil {
+			api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: invalid resourceVersion %q", registry.ErrNodeInvalid, rv))
+			return false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(request.Request.Context(), wait)
+	defer cancel()
+	changed, err := h.nodeRegistry.WaitForChange(ctx, since)
+	if err != nil {
+		h.handleNodeResponse(request, response, http.StatusOK, nil, err)
+		return false
+	}
+	if !changed {
+		response.Header().Set(HeaderResourceVersion, strconv.FormatUint(since, 10))
+		response.WriteHeader(http.StatusNotModified)
+		return false
+	}
+	return true
+}
+
+// pageLimit returns the effective page size for a list request, adding a Warning
+// header to response if the requested limit had to be reduced
+func (h *NodeHandler) pageLimit(request *restful.Request, response *restful.Response) (int, error) {
+	limitParam := request.QueryParameter("limit")
+	if limitParam == "" {
+		return h.defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("%w: invalid limit %q", registry.ErrNodeInvalid, limitParam)
+	}
+
+	switch {
+	case limit == 0:
+		return h.defaultPageSize, nil
+	case limit > h.maxPageSize:
+		response.Header().Add(HeaderWarning,
+			fmt.Sprintf(`299 - "limit %d exceeds the maximum page size, using %d"`, limit, h.maxPageSize))
+		return h.maxPageSize, nil
+	default:
+		return limit, nil
+	}
 }
 
-// RegisterNodeRoutes registers Node routes with the WebService
-func RegisterNodeRoutes(ws *restful.WebService, handler *NodeHandler) {
-	ws.Route(ws.POST("/nodes").To(handler.CreateNode))
-	ws.Route(ws.GET("/nodes").To(handler.ListNodes))
-	ws.Route(ws.GET("/nodes/{name}").To(handler.GetNode))
-	ws.Route(ws.PUT("/nodes/{name}").To(handler.UpdateNode))
-	ws.Route(ws.DELETE("/nodes/{name}").To(handler.DeleteNode))
+// nodeFilterFromRequest builds a NodeFilter from the ?phase=, ?labelSelector=,
+// ?fieldSelector= and ?schedulable= query parameters
+func nodeFilterFromRequest(request *restful.Request) (registry.NodeFilter, error) {
+	var filter registry.NodeFilter
+	if phaseParam := request.QueryParameter("phase"); phaseParam != "" {
+		phase, ok := api.ParseNodePhase(phaseParam)
+		if !ok {
+			return filter, fmt.Errorf("%w: unknown phase %q", registry.ErrNodeInvalid, phaseParam)
+		}
+		filter.Phase = phase
+	}
+
+	selector, err := labels.Parse(request.QueryParameter("labelSelector"))
+	if err != nil {
+		return filter, fmt.Errorf("%w: %v", registry.ErrNodeInvalid, err)
+	}
+	filter.LabelSelector = selector
+
+	fieldSelector, err := registry.ParseFieldSelector(request.QueryParameter("fieldSelector"))
+	if err != nil {
+		return filter, err
+	}
+	filter.FieldSelector = fieldSelector
+	filter.Schedulable = request.QueryParameter("schedulable") == "true"
+
+	return filter, nil
+}
+
+// streamNodes writes matching nodes as newline-delimited JSON, flushing after each one
+func (h *NodeHandler) streamNodes(request *restful.Request, response *restful.Response, filter registry.NodeFilter) {
+	w := h.streamWriter(request, response)
+	encoder := json.NewEncoder(w)
+	err := h.nodeRegistry.StreamNodes(request.Request.Context(), func(node *api.Node) error {
+		if !filter.Matches(node) {
+			return nil
+		}
+		return encoder.Encode(v1.ConvertFromInternal(node))
+	})
+	w.finish(h, err)
+}
+
+// RouteOption configures how RegisterNodeRoutes sets up the Node routes
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	timeouts    map[string]time.Duration
+	strict      map[string]bool
+	strictQuery bool
+}
+
+// WithRouteTimeout limits how long a route may take before the request fails with
+// 504. The route is written as "METHOD /path" relative to the web service root,
+// e.g. "GET /nodes/{name}".
+func WithRouteTimeout(route string, timeout time.Duration) RouteOption {
+	return func(o *routeOptions) {
+		o.timeouts[route] = timeout
+	}
+}
+
+// WithStrictDecoding makes a route reject request bodies containing fields the
+// target type doesn't have with 422, instead of ignoring them. The route is written
+// as for WithRouteTimeout.
+func WithStrictDecoding(route string) RouteOption {
+	return func(o *routeOptions) {
+		o.strict[route] = true
+	}
+}
+
+// WithStrictQueryParams makes every Node route reject requests with query
+// parameters the route doesn't declare with 400, so that typos such as
+// ?labelSelectr= don't silently do nothing
+func WithStrictQueryParams() RouteOption {
+	return func(o *routeOptions) {
+		o.strictQuery = true
+	}
+}
+
+// apply attaches the configured per-route filters to b
+func (o *routeOptions) apply(ws *restful.WebService, b *restful.RouteBuilder) *restful.RouteBuilder {
+	route := b.Build()
+	key := route.Method + " " + strings.TrimPrefix(route.Path, ws.RootPath())
+	if timeout, ok := o.timeouts[key]; ok && timeout > 0 {
+		b.Filter(api.TimeoutFilter(timeout))
+	}
+	if o.strict[key] {
+		b.Filter(strictDecodingFilter)
+	}
+	if o.strictQuery {
+		b.Filter(strictQueryFilter(route))
+	}
+	if filter := nodeScopeFilter(route); filter != nil {
+		b.Filter(filter)
+	}
+	return b
+}
+
+// strictQueryFilter rejects requests with query parameters that route doesn't declare
+func strictQueryFilter(route restful.Route) restful.FilterFunction {
+	known := map[string]bool{}
+	for _, param := range route.ParameterDocs {
+		if param.Kind() == restful.QueryParameterKind {
+			known[param.Data().Name] = true
+		}
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		var unknown []string
+		for name := range req.Request.URL.Query() {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			api.WriteError(req, resp, http.StatusBadRequest,
+				fmt.Errorf("%w: unknown query parameters: %s", registry.ErrNodeInvalid, strings.Join(unknown, ", ")))
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// RegisterNodeRoutes registers Node routes with the WebService. The routes speak
+// the v1 wire format, so ws is expected to be rooted at /api/v1.
+func RegisterNodeRoutes(ws *restful.WebService, handler *NodeHandler, opts ...RouteOption) {
+	o := &routeOptions{timeouts: map[string]time.Duration{}, strict: map[string]bool{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	query := func(b *restful.RouteBuilder, params ...string) *restful.RouteBuilder {
+		for _, param := range params {
+			b.Param(ws.QueryParameter(param, ""))
+		}
+		return b
+	}
+
+	routes := []*restful.RouteBuilder{
+		ws.POST("/nodes").To(handler.CreateNode),
+		query(ws.GET("/nodes").To(handler.ListNodes),
+			"phase", "labelSelector", "fieldSelector", "schedulable", "limit", "continue", "stream", "watch", "sendInitialEvents", "eventTypes", "wait", "resourceVersion"),
+		query(ws.GET("/nodes:export").To(handler.ExportNodes).Produces(MIME_NDJSON, MIME_GZIP), "format", "continue"),
+		query(ws.POST("/nodes:import").To(handler.ImportNodes).Consumes(MIME_NDJSON, restful.MIME_JSON, MIME_GZIP), "format", "overwrite"),
+		ws.POST("/nodes:label").To(handler.LabelNodes),
+		ws.POST("/nodes:batchDelete").To(handler.BatchDeleteNodes),
+		ws.DELETE("/nodes/self").To(handler.DeleteSelf),
+		ws.GET("/nodes/{name}").To(handler.GetNode),
+		ws.HEAD("/nodes/{name}").To(handler.NodeExists),
+		query(ws.PUT("/nodes/{name}").To(handler.UpdateNode), "merge"),
+		query(ws.PATCH("/nodes/{name}").To(handler.ApplyNode).Consumes(MIME_APPLY_PATCH), "fieldManager"),
+		ws.DELETE("/nodes/{name}").To(handler.DeleteNode),
+		ws.GET("/nodes/{name}/watch").To(handler.WatchNode),
+		ws.GET("/nodes/{name}/schedulable").To(handler.GetNodeSchedulability),
+		ws.PUT("/nodes/{name}/cordon").To(handler.CordonNode),
+		ws.DELETE("/nodes/{name}/cordon").To(handler.UncordonNode),
+		ws.GET("/nodes/{name}/conditions/history").To(handler.GetNodeStatusHistory),
+		ws.GET("/nodepools/{pool}/summary").To(handler.GetPoolSummary),
+	}
+	for _, b := range routes {
+		ws.Route(o.apply(ws, b))
+	}
 }
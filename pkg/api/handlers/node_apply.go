@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_APPLY_PATCH is the content type of server-side apply requests
+const MIME_APPLY_PATCH = "application/apply-patch+json"
+
+// ApplyNode handles PATCH requests that apply a v1 Node configuration on behalf of
+// the field manager named by the required ?fieldManager= parameter, creating the
+// Node if it doesn't exist
+func (h *NodeHandler) ApplyNode(request *restful.Request, response *restful.Response) {
+	fieldManager := request.QueryParameter("fieldManager")
+	if fieldManager == "" {
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: fieldManager is required for apply requests", registry.ErrNodeInvalid))
+		return
+	}
+
+	applied, err := io.ReadAll(request.Request.Body)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: %v", registry.ErrNodeInvalid, err))
+		return
+	}
+
+	// The configuration is merged as JSON, but it must still be a v1 Node
+	external := &v1.Node{}
+	if err := decodeEntity(request, applied, external); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+	if err := h.checkTypeMeta(external); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+
+	node, err := h.nodeRegistry.ApplyNode(request.Request.Context(), request.PathParameter("name"), fieldManager, applied)
+	h.handleNodeResponse(request, response, http.StatusOK, v1.ConvertFromInternal(node), err)
+}
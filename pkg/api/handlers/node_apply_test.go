@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestApplyNode(t *testing.T) {
+	applied := `{"metadata": {"name": "test-node", "labels": {"team": "infra"}}}`
+
+	apply := func(container *restful.Container, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", path, strings.NewReader(applied))
+		req.Header.Set("Content-Type", MIME_APPLY_PATCH)
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject apply without a field manager", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			resp := apply(container, "/api/v1/nodes/test-node")
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			_, err := nodeRegistry.GetNode(context.Background(), "test-node")
+			assert.ErrorIs(t, err, registry.ErrNodeNotFound)
+		})
+	})
+
+	t.Run("should record ownership under the field manager", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			resp := apply(container, "/api/v1/nodes/test-node?fieldManager=bootstrap")
+
+			require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+			node, err := nodeRegistry.GetNode(context.Background(), "test-node")
+			require.NoError(t, err)
+			assert.Equal(t, "infra", node.Labels["team"])
+
+			var managers map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal([]byte(node.Annotations[registry.FieldManagersAnnotation]), &managers))
+			assert.JSONEq(t, applied, string(managers["bootstrap"]))
+		})
+	})
+
+	t.Run("should reject with 422 a patch that leaves the node invalid", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			require.Equal(t, http.StatusOK, apply(container, "/api/v1/nodes/test-node?fieldManager=bootstrap").Code)
+
+			req := httptest.NewRequest("PATCH", "/api/v1/nodes/test-node?fieldManager=other",
+				strings.NewReader(`{"metadata": {"name": "test-node", "labels": {"-team": "infra"}}}`))
+			req.Header.Set("Content-Type", MIME_APPLY_PATCH)
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+			node, err := nodeRegistry.GetNode(context.Background(), "test-node")
+			require.NoError(t, err)
+			assert.NotContains(t, node.Labels, "-team")
+		})
+	})
+	t.Run("should reject other kinds in strict mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry, WithStrictTypeMeta()))
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+
+			send := func(method, path, contentType, body string) int {
+				req := httptest.NewRequest(method, path, strings.NewReader(body))
+				req.Header.Set("Content-Type", contentType)
+				resp := httptest.NewRecorder()
+				container.ServeHTTP(resp, req)
+				return resp.Code
+			}
+			pod := `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test-node", "labels": {"team": "infra"}}}`
+
+			assert.Equal(t, http.StatusUnprocessableEntity, send("PATCH", "/api/v1/nodes/test-node?fieldManager=bootstrap", MIME_APPLY_PATCH, pod))
+			assert.Equal(t, http.StatusUnprocessableEntity, send("PUT", "/api/v1/nodes/test-node?merge=true", restful.MIME_JSON, pod))
+			node, err := nodeRegistry.GetNode(context.Background(), "test-node")
+			require.NoError(t, err)
+			assert.NotContains(t, node.Labels, "team")
+
+			typed := `{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "test-node", "labels": {"team": "infra"}}}`
+			assert.Equal(t, http.StatusOK, send("PATCH", "/api/v1/nodes/test-node?fieldManager=bootstrap", MIME_APPLY_PATCH, typed))
+			node, err = nodeRegistry.GetNode(context.Background(), "test-node")
+			require.NoError(t, err)
+			assert.Equal(t, "infra", node.Labels["team"])
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// BatchDeleteRequest is the body of a POST /nodes:batchDelete request
+type BatchDeleteRequest struct {
+	Names []string `json:"names"`
+}
+
+// BatchDeleteNodes handles POST requests to delete a list of Nodes by name,
+// replying with the outcome for each name
+func (h *NodeHandler) BatchDeleteNodes(request *restful.Request, response *restful.Response) {
+	body := &BatchDeleteRequest{}
+	if err := readEntity(request, body); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+	if len(body.Names) == 0 {
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: no names to delete", registry.ErrNodeInvalid))
+		return
+	}
+
+	results := h.nodeRegistry.DeleteNodesByName(request.Request.Context(), body.Names)
+	api.WriteResponse(response, http.StatusOK, results)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestBatchDeleteNodes(t *testing.T) {
+	t.Run("should report the outcome for each name", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			for _, name := range []string{"test-node-1", "test-node-2", "kept-node"} {
+				require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}))
+			}
+
+			body, _ := json.Marshal(BatchDeleteRequest{Names: []string{"test-node-1", "missing-node", "test-node-2"}})
+			req := httptest.NewRequest("POST", "/api/v1/nodes:batchDelete", bytes.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var results []registry.DeleteResult
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+			assert.Equal(t, []registry.DeleteResult{
+				{Name: "test-node-1", Status: registry.DeleteStatusDeleted},
+				{Name: "missing-node", Status: registry.DeleteStatusNotFound},
+				{Name: "test-node-2", Status: registry.DeleteStatusDeleted},
+			}, results)
+
+			nodes, err := nodeRegistry.ListNodes(ctx)
+			require.NoError(t, err)
+			require.Len(t, nodes, 1)
+			assert.Equal(t, "kept-node", nodes[0].Name)
+		})
+	})
+
+	t.Run("should return bad request without names", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))))
+
+			req := httptest.NewRequest("POST", "/api/v1/nodes:batchDelete", bytes.NewReader([]byte(`{"names":[]}`)))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+}
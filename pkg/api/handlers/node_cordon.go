@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gokube/pkg/api"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// CordonNodeRequest is the body of a PUT /nodes/{name}/cordon request
+type CordonNodeRequest struct {
+	Reason string `json:"reason,omitempty"`
+	By     string `json:"by,omitempty"`
+}
+
+// GetNodeSchedulability handles GET requests for the cordon state of a Node
+func (h *NodeHandler) GetNodeSchedulability(request *restful.Request, response *restful.Response) {
+	s, err := h.nodeRegistry.GetNodeSchedulability(request.Request.Context(), request.PathParameter("name"))
+	h.handleNodeResponse(request, response, http.StatusOK, s, err)
+}
+
+// CordonNode handles PUT requests to cordon a Node and replies with its new cordon
+// state. Cordoning a cordoned Node again replaces the reason.
+func (h *NodeHandler) CordonNode(request *restful.Request, response *restful.Response) {
+	body := &CordonNodeRequest{}
+	if request.Request.ContentLength != 0 {
+		if err := readEntity(request, body); err != nil {
+			api.WriteError(request, response, decodeStatusCode(err), err)
+			return
+		}
+	}
+
+	ctx := request.Request.Context()
+	name := request.PathParameter("name")
+	if err := h.nodeRegistry.CordonNode(ctx, name, body.Reason, body.By); err != nil {
+		h.handleNodeResponse(request, response, http.StatusOK, nil, err)
+		return
+	}
+	h.GetNodeSchedulability(request, response)
+}
+
+// UncordonNode handles DELETE requests to uncordon a Node and replies with its new
+// cordon state. Uncordoning a schedulable Node succeeds and changes nothing.
+func (h *NodeHandler) UncordonNode(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	name := request.PathParameter("name")
+	if err := h.nodeRegistry.UncordonNode(ctx, name); err != nil {
+		h.handleNodeResponse(request, response, http.StatusOK, nil, err)
+		return
+	}
+	h.GetNodeSchedulability(request, response)
+}
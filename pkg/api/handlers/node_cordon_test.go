@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestNodeSchedulability(t *testing.T) {
+	t.Run("should reflect cordon state", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			nodeRegistry := registry.NewNodeRegistry(store, registry.WithClock(clock.NewFakeClock(now)))
+			handler := NewNodeHandler(nodeRegistry)
+
+			RegisterNodeRoutes(ws, handler)
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+
+			schedulability := func(method string, body []byte) registry.Schedulability {
+				t.Helper()
+				path := "/api/v1/nodes/test-node/schedulable"
+				if method != "GET" {
+					path = "/api/v1/nodes/test-node/cordon"
+				}
+				req := httptest.NewRequest(method, path, bytes.NewReader(body))
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				resp := httptest.NewRecorder()
+
+				container.ServeHTTP(resp, req)
+
+				require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+				var s registry.Schedulability
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &s))
+				return s
+			}
+
+			assert.Equal(t, registry.Schedulability{}, schedulability("GET", nil))
+
+			body, _ := json.Marshal(CordonNodeRequest{Reason: "kernel upgrade", By: "ops"})
+			schedulability("PUT", body)
+
+			s := schedulability("GET", nil)
+			assert.True(t, s.Unschedulable)
+			assert.Equal(t, "kernel upgrade", s.Reason)
+			assert.Equal(t, "ops", s.By)
+			require.NotNil(t, s.Since)
+			assert.True(t, now.Equal(*s.Since))
+
+			// Uncordoning twice is fine
+			assert.Equal(t, registry.Schedulability{}, schedulability("DELETE", nil))
+			assert.Equal(t, registry.Schedulability{}, schedulability("DELETE", nil))
+		})
+	})
+
+	t.Run("should return not found for a missing node", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			for _, method := range []string{"GET", "PUT", "DELETE"} {
+				path := "/api/v1/nodes/non-existent-node/cordon"
+				if method == "GET" {
+					path = "/api/v1/nodes/non-existent-node/schedulable"
+				}
+				req := httptest.NewRequest(method, path, nil)
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				resp := httptest.NewRecorder()
+
+				container.ServeHTTP(resp, req)
+
+				assert.Equal(t, http.StatusNotFound, resp.Code, method)
+			}
+		})
+	})
+}
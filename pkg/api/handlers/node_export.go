@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_NDJSON is the content type for newline-delimited JSON streams
+const MIME_NDJSON = "application/x-ndjson"
+
+// MIME_GZIP is the content type for tar.gz node archives
+const MIME_GZIP = "application/gzip"
+
+// exportFormat returns the archive format requested with ?format=, defaulting to
+// ndjson. The format can also be implied by a gzip request body on import.
+func exportFormat(request *restful.Request) (string, error) {
+	format := request.QueryParameter("format")
+	if format == "" && request.Request.Header.Get("Content-Type") == MIME_GZIP {
+		format = "targz"
+	}
+	switch format {
+	case "", "ndjson":
+		return "ndjson", nil
+	case "targz":
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported export format %q", registry.ErrNodeInvalid, format)
+	}
+}
+
+// ExportNodes handles GET requests to stream all Nodes as newline-delimited JSON,
+// or as a tar.gz archive with one file per node when format=targz
+func (h *NodeHandler) ExportNodes(request *restful.Request, response *restful.Response) {
+	format, err := exportFormat(request)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, err)
+		return
+	}
+
+	w := h.streamWriter(request, response)
+	if format == "targz" {
+		w.contentType = MIME_GZIP
+		err = h.nodeRegistry.ExportNodesArchive(request.Request.Context(), w)
+	} else {
+		err = h.nodeRegistry.ExportNodes(request.Request.Context(), w, request.QueryParameter("continue"))
+	}
+	w.finish(h, err)
+}
+
+// ImportNodes handles POST requests to create Nodes from a newline-delimited JSON
+// stream or from a tar.gz archive written by ExportNodes
+func (h *NodeHandler) ImportNodes(request *restful.Request, response *restful.Response) {
+	format, err := exportFormat(request)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, err)
+		return
+	}
+
+	overwrite := request.QueryParameter("overwrite") == "true"
+	var result *registry.ImportResult
+	if format == "targz" {
+		result, err = h.nodeRegistry.ImportNodesArchive(request.Request.Context(), request.Request.Body, overwrite)
+	} else {
+		result, err = h.nodeRegistry.ImportNodes(request.Request.Context(), request.Request.Body, overwrite)
+	}
+	h.handleNodeResponse(request, response, http.StatusOK, result, err)
+}
+
+// ndjsonWriter defers writing the response header until the first line is written,
+// so errors that happen before any output can still be reported with a proper status.
+// Every write is flushed so clients see lines as soon as they are produced.
+type ndjsonWriter struct {
+	request  *restful.Request
+	response *restful.Response
+	// contentType overrides MIME_NDJSON for other streamed formats
+	contentType string
+	// idleTimeout is how long a single write may take, see extendDeadline
+	idleTimeout time.Duration
+	wroteHeader bool
+}
+
+// streamWriter returns an ndjsonWriter for response using the handler's stream
+// settings
+func (h *NodeHandler) streamWriter(request *restful.Request, response *restful.Response) *ndjsonWriter {
+	return &ndjsonWriter{request: request, response: response, idleTimeout: h.streamIdleTimeout}
+}
+
+// extendDeadline replaces the server's WriteTimeout, which bounds the whole response,
+// with a deadline of idleTimeout from now, or none if idleTimeout is 0. A stream that
+// the client keeps reading is then never cut off, while one whose client stopped
+// reading still fails. Writers that can't set deadlines are left alone.
+func (w *ndjsonWriter) extendDeadline() {
+	var deadline time.Time
+	if w.idleTimeout > 0 {
+		deadline = time.Now().Add(w.idleTimeout)
+	}
+	_ = http.NewResponseController(w.response.ResponseWriter).SetWriteDeadline(deadline)
+}
+
+func (w *ndjsonWriter) writeHeader() {
+	w.extendDeadline()
+	contentType := w.contentType
+	if contentType == "" {
+		contentType = MIME_NDJSON
+	}
+	w.response.Header().Set("Content-Type", contentType)
+	w.response.WriteHeader(http.StatusOK)
+	w.wroteHeader = true
+}
+
+func (w *ndjsonWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.writeHeader()
+	}
+	w.extendDeadline()
+	n, err := w.response.Write(p)
+	w.response.Flush()
+	return n, err
+}
+
+// finish completes the response once the stream has ended with err
+func (w *ndjsonWriter) finish(h *NodeHandler, err error) {
+	switch {
+	case err != nil && !w.wroteHeader:
+		h.handleNodeResponse(w.request, w.response, http.StatusOK, nil, err)
+	case err != nil:
+		// The status has already been sent, all we can do is cut the stream short
+		log.Printf("Error streaming nodes: %v", err)
+	case !w.wroteHeader:
+		// Nothing matched, still reply with an empty stream
+		w.writeHeader()
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mockStorage "gokube/mocks/pkg/storage"
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/runtime"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportImportNodes(t *testing.T) {
+	t.Run("should export and re-import nodes", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, name := range []string{"test-node-1", "test-node-2"} {
+				err := nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}})
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes:export", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, MIME_NDJSON, resp.Header().Get("Content-Type"))
+			lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+			require.Len(t, lines, 2)
+
+			require.NoError(t, nodeRegistry.DeleteNode(ctx, "test-node-2"))
+
+			req = httptest.NewRequest("POST", "/api/v1/nodes:import", bytes.NewReader(resp.Body.Bytes()))
+			req.Header.Set("Content-Type", MIME_NDJSON)
+			resp = httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var result registry.ImportResult
+			err := json.Unmarshal(resp.Body.Bytes(), &result)
+			require.NoError(t, err)
+			assert.Equal(t, registry.ImportResult{Created: 1, Skipped: 1}, result)
+
+			_, err = nodeRegistry.GetNode(ctx, "test-node-2")
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("should export and re-import a tar.gz archive", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, name := range []string{"test-node-1", "test-node-2", "test-node-3"} {
+				err := nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}})
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes:export?format=targz", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, MIME_GZIP, resp.Header().Get("Content-Type"))
+			archive := resp.Body.Bytes()
+
+			for _, name := range []string{"test-node-1", "test-node-2", "test-node-3"} {
+				require.NoError(t, nodeRegistry.DeleteNode(ctx, name))
+			}
+
+			req = httptest.NewRequest("POST", "/api/v1/nodes:import", bytes.NewReader(archive))
+			req.Header.Set("Content-Type", MIME_GZIP)
+			resp = httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var result registry.ImportResult
+			err := json.Unmarshal(resp.Body.Bytes(), &result)
+			require.NoError(t, err)
+			assert.Equal(t, registry.ImportResult{Created: 3}, result)
+		})
+	})
+
+	t.Run("should reject an unknown export format", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes:export?format=zip", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+}
+
+func TestStreamNodes(t *testing.T) {
+	t.Run("should stream all nodes as ndjson", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			names := []string{"test-node-1", "test-node-2", "test-node-3"}
+			for _, name := range names {
+				err := nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}})
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?stream=ndjson", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, MIME_NDJSON, resp.Header().Get("Content-Type"))
+
+			var streamed []string
+			decoder := json.NewDecoder(resp.Body)
+			for decoder.More() {
+				var node api.Node
+				require.NoError(t, decoder.Decode(&node))
+				streamed = append(streamed, node.Name)
+			}
+			assert.ElementsMatch(t, names, streamed)
+		})
+	})
+
+	t.Run("should reject an unknown stream format", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?stream=xml", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+
+	t.Run("should send nodes before storage has been fully read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		release := make(chan struct{})
+		mockStore := mockStorage.NewMockStorage(ctrl)
+		mockStore.EXPECT().Walk(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(obj runtime.Object) error) error {
+				if err := fn(&api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-1"}}); err != nil {
+					return err
+				}
+				<-release
+				return fn(&api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-2"}})
+			})
+
+		handler := NewNodeHandler(registry.NewNodeRegistry(mockStore))
+
+		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, handler)
+
+			server := httptest.NewServer(container)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/v1/nodes?stream=ndjson")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			// The first node must arrive while storage is still blocked on the second
+			reader := bufio.NewReader(resp.Body)
+			var node api.Node
+			line, err := reader.ReadBytes('\n')
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(line, &node))
+			assert.Equal(t, "test-node-1", node.Name)
+
+			close(release)
+
+			line, err = reader.ReadBytes('\n')
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(line, &node))
+			assert.Equal(t, "test-node-2", node.Name)
+		})
+	})
+}
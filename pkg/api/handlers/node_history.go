@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// GetNodeStatusHistory handles GET requests for the recent status transitions of a
+// Node, oldest first
+func (h *NodeHandler) GetNodeStatusHistory(request *restful.Request, response *restful.Response) {
+	history, err := h.nodeRegistry.GetStatusHistory(request.Request.Context(), request.PathParameter("name"))
+	h.handleNodeResponse(request, response, http.StatusOK, history, err)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestNodeStatusHistory(t *testing.T) {
+	t.Run("should list status transitions oldest first", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+			node := &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}, Status: api.NodeNotReady}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+			node.Status = api.NodeReady
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes/test-node/conditions/history", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var history []registry.StatusTransition
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &history))
+			require.Len(t, history, 2)
+			assert.Equal(t, api.NodeNotReady, history[0].To)
+			assert.Equal(t, api.NodeReady, history[1].To)
+		})
+	})
+
+	t.Run("should reply 404 for unknown nodes", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))))
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes/missing/conditions/history", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusNotFound, resp.Code)
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"gokube/pkg/api"
+	"gokube/pkg/labels"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// LabelNodesRequest is the body of a POST /nodes:label request
+type LabelNodesRequest struct {
+	// Selector picks the Nodes to relabel; it must not be empty
+	Selector string            `json:"selector"`
+	Add      map[string]string `json:"add,omitempty"`
+	Remove   []string          `json:"remove,omitempty"`
+}
+
+// LabelNodes handles POST requests to add and remove labels on all selected Nodes
+func (h *NodeHandler) LabelNodes(request *restful.Request, response *restful.Response) {
+	body := &LabelNodesRequest{}
+	if err := readEntity(request, body); err != nil {
+		api.WriteError(request, response, decodeStatusCode(err), err)
+		return
+	}
+
+	selector, err := labels.Parse(body.Selector)
+	if err != nil {
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: %v", registry.ErrNodeInvalid, err))
+		return
+	}
+	if selector.Empty() {
+		api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: a selector is required", registry.ErrNodeInvalid))
+		return
+	}
+
+	results, err := h.nodeRegistry.LabelNodes(request.Request.Context(), selector, body.Add, body.Remove)
+	h.handleNodeResponse(request, response, http.StatusOK, results, err)
+}
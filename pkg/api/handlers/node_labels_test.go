@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestLabelNodes(t *testing.T) {
+	t.Run("should label matching nodes only", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for name, zone := range map[string]string{
+				"zone-a-node-1": "a",
+				"zone-a-node-2": "a",
+				"zone-b-node":   "b",
+			} {
+				node := &api.Node{ObjectMeta: api.ObjectMeta{Name: name, Labels: map[string]string{"zone": zone}}}
+				require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+			}
+
+			body, _ := json.Marshal(LabelNodesRequest{Selector: "zone=a", Add: map[string]string{"tier": "gpu"}})
+			req := httptest.NewRequest("POST", "/api/v1/nodes:label", bytes.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var results []registry.LabelResult
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+			assert.ElementsMatch(t, []registry.LabelResult{
+				{Name: "zone-a-node-1", Changed: true},
+				{Name: "zone-a-node-2", Changed: true},
+			}, results)
+
+			for _, name := range []string{"zone-a-node-1", "zone-a-node-2"} {
+				node, err := nodeRegistry.GetNode(ctx, name)
+				require.NoError(t, err)
+				assert.Equal(t, "gpu", node.Labels["tier"])
+			}
+
+			untouched, err := nodeRegistry.GetNode(ctx, "zone-b-node")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"zone": "b"}, untouched.Labels)
+		})
+	})
+
+	t.Run("should require a selector", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			body, _ := json.Marshal(LabelNodesRequest{Add: map[string]string{"tier": "gpu"}})
+			req := httptest.NewRequest("POST", "/api/v1/nodes:label", bytes.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// ErrForbidden is returned when a node acts on a Node other than itself
+var ErrForbidden = errors.New("forbidden")
+
+// DeleteSelf handles DELETE /nodes/self, which lets a shutting-down node deregister
+// without knowing anything but its own credentials. The Node to delete is the one
+// named by the client certificate; a node whose Node doesn't exist is refused
+// rather than told it is gone. The route shadows the path of a Node named "self".
+func (h *NodeHandler) DeleteSelf(request *restful.Request, response *restful.Response) {
+	name, ok := api.NodeIdentity(request.Request)
+	if !ok {
+		api.WriteError(request, response, http.StatusUnauthorized, errors.New("deleting self requires a node client certificate"))
+		return
+	}
+
+	exists, err := h.nodeRegistry.NodeExists(request.Request.Context(), name)
+	if err != nil {
+		h.handleNodeResponse(request, response, http.StatusNoContent, nil, err)
+		return
+	}
+	if !exists {
+		api.WriteError(request, response, http.StatusForbidden, fmt.Errorf("%w: node %s is not registered", ErrForbidden, name))
+		return
+	}
+
+	err = h.nodeRegistry.DeleteNode(request.Request.Context(), name)
+	h.handleNodeResponse(request, response, http.StatusNoContent, name, err)
+}
+
+// nodeScopeFilter keeps a node identified by its client certificate to reporting
+// its own status on route: the only per-node write it may make is a plain PUT of
+// its own /nodes/{name}, which UpdateNode turns into a status update, so a node
+// can't uncordon, relabel or delete itself out of a cordon an operator or the
+// registry put it in. Writes to the whole collection, such as /nodes:batchDelete,
+// aren't allowed at all. It returns nil for routes every node may use: reads,
+// DELETE /nodes/self and POST /nodes, where CreateNode checks the name in the body.
+func nodeScopeFilter(route restful.Route) restful.FilterFunction {
+	switch {
+	case route.Method == http.MethodGet || route.Method == http.MethodHead:
+		return nil
+	case strings.HasSuffix(route.Path, "/nodes/self"),
+		route.Method == http.MethodPost && strings.HasSuffix(route.Path, "/nodes"):
+		return nil
+	}
+
+	perNode := strings.Contains(route.Path, "{name}")
+	statusWrite := route.Method == http.MethodPut && strings.HasSuffix(route.Path, "/nodes/{name}")
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		identity, ok := api.NodeIdentity(req.Request)
+		own := perNode && req.PathParameter("name") == identity
+		if ok && (!own || !statusWrite || req.QueryParameter("merge") == "true") {
+			target := "other nodes"
+			switch {
+			case own:
+				target = "more than its own status"
+			case perNode:
+				target = "node " + req.PathParameter("name")
+			}
+			api.WriteError(req, resp, http.StatusForbidden, fmt.Errorf("%w: node %s may not modify %s", ErrForbidden, identity, target))
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// updateOwnStatus handles a node's PUT of its own Node: only the fields its agent
+// reports, the status and the node info, are taken from the body, and everything
+// else, from the cordon to the labels, stays as it was
+func (h *NodeHandler) updateOwnStatus(request *restful.Request, response *restful.Response, reported *api.Node) {
+	var updated *api.Node
+	err := h.nodeRegistry.UpdateNodeWithRetry(request.Request.Context(), reported.Name, func(node *api.Node) error {
+		node.Status = reported.Status
+		node.Info = reported.Info
+		updated = node
+		return nil
+	})
+	h.handleNodeResponse(request, response, http.StatusOK, v1.ConvertFromInternal(updated), err)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestDeleteSelf(t *testing.T) {
+	deleteAs := func(container *restful.Container, node, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", path, nil)
+		if node != "" {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: api.NodeUserPrefix + node}}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+		return resp
+	}
+
+	withNodes := func(t *testing.T, test func(nodeRegistry *registry.NodeRegistry, container *restful.Container)) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			for _, name := range []string{"node-1", "node-2"} {
+				require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}))
+			}
+			test(nodeRegistry, container)
+		})
+	}
+
+	t.Run("should let a node delete itself", func(t *testing.T) {
+		withNodes(t, func(nodeRegistry *registry.NodeRegistry, container *restful.Container) {
+			resp := deleteAs(container, "node-1", "/api/v1/nodes/self")
+
+			require.Equal(t, http.StatusNoContent, resp.Code, resp.Body.String())
+			exists, err := nodeRegistry.NodeExists(context.Background(), "node-1")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	})
+
+	t.Run("should forbid a node to delete another node", func(t *testing.T) {
+		withNodes(t, func(nodeRegistry *registry.NodeRegistry, container *restful.Container) {
+			resp := deleteAs(container, "node-1", "/api/v1/nodes/node-2")
+
+			assert.Equal(t, http.StatusForbidden, resp.Code)
+			exists, err := nodeRegistry.NodeExists(context.Background(), "node-2")
+			require.NoError(t, err)
+			assert.True(t, exists)
+		})
+	})
+
+	t.Run("should forbid an identity that doesn't match a node", func(t *testing.T) {
+		withNodes(t, func(_ *registry.NodeRegistry, container *restful.Container) {
+			assert.Equal(t, http.StatusForbidden, deleteAs(container, "node-3", "/api/v1/nodes/self").Code)
+		})
+	})
+
+	t.Run("should require a node identity", func(t *testing.T) {
+		withNodes(t, func(_ *registry.NodeRegistry, container *restful.Container) {
+			assert.Equal(t, http.StatusUnauthorized, deleteAs(container, "", "/api/v1/nodes/self").Code)
+		})
+	})
+}
+
+func TestNodeScope(t *testing.T) {
+	withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+		nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+		for _, name := range []string{"node-1", "node-2"} {
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}))
+		}
+
+		requestAs := func(node, method, path, body string) int {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: api.NodeUserPrefix + node}}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, req)
+			return resp.Code
+		}
+
+		t.Run("should forbid a node to change other nodes", func(t *testing.T) {
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "POST", "/api/v1/nodes:batchDelete", `{"names":["node-2"]}`))
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "PUT", "/api/v1/nodes/node-2", `{"metadata":{"name":"node-2"}}`))
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "PUT", "/api/v1/nodes/node-2/cordon", ""))
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "POST", "/api/v1/nodes", `{"metadata":{"name":"node-3"}}`))
+
+			exists, err := nodeRegistry.NodeExists(context.Background(), "node-2")
+			require.NoError(t, err)
+			assert.True(t, exists)
+			_, err = nodeRegistry.GetNode(context.Background(), "node-3")
+			assert.ErrorIs(t, err, registry.ErrNodeNotFound)
+		})
+
+		t.Run("should let a node read all nodes and change itself", func(t *testing.T) {
+			assert.Equal(t, http.StatusOK, requestAs("node-1", "GET", "/api/v1/nodes/node-2", ""))
+			assert.Equal(t, http.StatusOK, requestAs("node-1", "PUT", "/api/v1/nodes/node-1", `{"metadata":{"name":"node-1"},"status":"Ready"}`))
+		})
+
+		t.Run("should forbid a node to uncordon itself", func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, nodeRegistry.CordonNode(ctx, "node-1", "awaiting approval", "operator"))
+
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "DELETE", "/api/v1/nodes/node-1/cordon", ""))
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "PUT", "/api/v1/nodes/node-1?merge=true", `{"metadata":{"name":"node-1"},"spec":{"unschedulable":false}}`))
+			assert.Equal(t, http.StatusForbidden, requestAs("node-1", "DELETE", "/api/v1/nodes/node-1", ""))
+
+			node, err := nodeRegistry.GetNode(ctx, "node-1")
+			require.NoError(t, err)
+			assert.True(t, node.Spec.Unschedulable)
+		})
+
+		t.Run("should only take the status from a node's update of itself", func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, nodeRegistry.CordonNode(ctx, "node-1", "awaiting approval", "operator"))
+
+			body := `{"metadata":{"name":"node-1","labels":{"approved":"true"}},"spec":{"unschedulable":false},` +
+				`"status":"NotReady","nodeInfo":{"kubeletVersion":"v1.30.2"}}`
+			require.Equal(t, http.StatusOK, requestAs("node-1", "PUT", "/api/v1/nodes/node-1", body))
+
+			node, err := nodeRegistry.GetNode(ctx, "node-1")
+			require.NoError(t, err)
+			assert.Equal(t, api.NodeNotReady, node.Status)
+			require.NotNil(t, node.Info)
+			assert.Equal(t, "v1.30.2", node.Info.KubeletVersion)
+			assert.True(t, node.Spec.Unschedulable)
+			assert.Equal(t, "awaiting approval", node.Spec.CordonReason)
+			assert.NotContains(t, node.Labels, "approved")
+		})
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_TABLE is the content type of node lists rendered as a v1.Table
+const MIME_TABLE = restful.MIME_JSON + ";as=" + v1.KindTable
+
+// nodeTableColumns are the columns of a node Table, in the order of the cells of
+// each row
+var nodeTableColumns = []v1.TableColumnDefinition{
+	{Name: "Name", Type: "string", Format: "name", Description: "Name of the node"},
+	{Name: "Status", Type: "string", Description: "Status of the node"},
+	{Name: "Roles", Type: "string", Description: "Roles of the node, from its " + api.LabelNodeRolePrefix + " labels"},
+	{Name: "Age", Type: "string", Description: "Time since the node was created"},
+}
+
+// acceptsTable reports whether the request asks for a list as a Table with
+// Accept: application/json;as=Table
+func acceptsTable(request *restful.Request) bool {
+	for _, accept := range strings.Split(request.Request.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == restful.MIME_JSON && params["as"] == v1.KindTable {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNodeTable writes nodes as a Table, with ages relative to now
+func writeNodeTable(response *restful.Response, nodes []*api.Node, now time.Time) {
+	table := &v1.Table{
+		TypeMeta:          v1.TypeMeta{APIVersion: v1.Version, Kind: v1.KindTable},
+		ColumnDefinitions: nodeTableColumns,
+		Rows:              make([]v1.TableRow, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		table.Rows = append(table.Rows, v1.TableRow{Cells: []interface{}{
+			node.Name,
+			string(node.Status),
+			nodeRoles(node),
+			humanDuration(now.Sub(node.CreationTimestamp)),
+		}})
+	}
+	if err := response.WriteHeaderAndJson(http.StatusOK, table, MIME_TABLE); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// nodeRoles lists the roles of node, comma separated, or "<none>"
+func nodeRoles(node *api.Node) string {
+	roles := node.Roles()
+	if len(roles) == 0 {
+		return "<none>"
+	}
+	return strings.Join(roles, ",")
+}
+
+// humanDuration formats d in its largest whole unit, like 3d or 45s
+func humanDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
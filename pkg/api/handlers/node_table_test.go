@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestListNodesAsTable(t *testing.T) {
+	t.Run("should return a row per node", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{
+				ObjectMeta: api.ObjectMeta{Name: "node-a", Labels: map[string]string{api.LabelNodeRolePrefix + api.NodeRoleControlPlane: ""}},
+				Status:     api.NodeReady,
+			}))
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "node-b"}}))
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes", nil)
+			req.Header.Set("Accept", MIME_TABLE)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, MIME_TABLE, resp.Header().Get("Content-Type"))
+
+			var table v1.Table
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &table))
+			assert.Equal(t, v1.KindTable, table.Kind)
+			require.NotEmpty(t, table.ColumnDefinitions)
+			assert.Equal(t, "Name", table.ColumnDefinitions[0].Name)
+			require.Len(t, table.Rows, 2)
+			assert.Equal(t, []interface{}{"node-a", "Ready", "control-plane", "0s"}, table.Rows[0].Cells)
+			assert.Equal(t, []interface{}{"node-b", "Unknown", "<none>", "0s"}, table.Rows[1].Cells)
+		})
+	})
+
+	t.Run("should return full nodes to plain JSON clients", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "node-a"}}))
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes", nil)
+			req.Header.Set("Accept", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var nodes []*v1.Node
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+			assert.Len(t, nodes, 1)
+		})
+	})
+}
+
+func TestHumanDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		-time.Second:     "0s",
+		45 * time.Second: "45s",
+		90 * time.Minute: "1h",
+		50 * time.Hour:   "2d",
+	} {
+		assert.Equal(t, want, humanDuration(d))
+	}
+}
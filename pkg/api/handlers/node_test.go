@@ -5,13 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	mockStorage "gokube/mocks/pkg/storage"
 	"gokube/pkg/api"
 	"gokube/pkg/registry"
+	"gokube/pkg/runtime"
 	"gokube/pkg/storage"
 
 	"github.com/emicklei/go-restful/v3"
@@ -60,6 +64,69 @@ func TestCreateNode(t *testing.T) {
 		})
 	})
 
+	t.Run("should return the existing node for If-None-Match: *", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+
+			RegisterNodeRoutes(ws, handler)
+
+			create := func(body string, header string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(body))
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				if header != "" {
+					req.Header.Set("If-None-Match", header)
+				}
+				resp := httptest.NewRecorder()
+				container.ServeHTTP(resp, req)
+				return resp
+			}
+
+			resp := create(`{"metadata": {"name": "test-node", "uid": "first"}}`, "*")
+			require.Equal(t, http.StatusCreated, resp.Code)
+
+			resp = create(`{"metadata": {"name": "test-node", "uid": "second"}}`, "*")
+			require.Equal(t, http.StatusOK, resp.Code)
+			var existing api.Node
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &existing))
+			assert.Equal(t, "first", existing.UID)
+
+			resp = create(`{"metadata": {"name": "test-node", "uid": "third"}}`, "")
+			assert.Equal(t, http.StatusConflict, resp.Code)
+		})
+	})
+
+	t.Run("should point the Location header at the created node", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, body := range []string{
+				`{"metadata": {"name": "test-node"}}`,
+				`{"metadata": {"generateName": "worker-"}}`,
+			} {
+				req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(body))
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				resp := httptest.NewRecorder()
+
+				container.ServeHTTP(resp, req)
+
+				require.Equal(t, http.StatusCreated, resp.Code)
+				var created api.Node
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+				assert.NotEmpty(t, created.Name)
+				assert.Equal(t, "/api/v1/nodes/"+created.Name, resp.Header().Get("Location"))
+
+				req = httptest.NewRequest("GET", resp.Header().Get("Location"), nil)
+				resp = httptest.NewRecorder()
+				container.ServeHTTP(resp, req)
+				assert.Equal(t, http.StatusOK, resp.Code)
+			}
+		})
+	})
+
 	t.Run("should return bad request for invalid node", func(t *testing.T) {
 		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
 			store := storage.NewEtcdStorage(etcdServer)
@@ -86,6 +153,46 @@ func TestCreateNode(t *testing.T) {
 		})
 	})
 
+	t.Run("should name the field that has the wrong type", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+
+			RegisterNodeRoutes(ws, handler)
+
+			body := `{"metadata": {"name": "test-node"}, "spec": {"providerID": 42}}`
+			req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+			var status api.Status
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+			assert.Equal(t, api.StatusReasonBadRequest, status.Reason)
+			assert.Contains(t, status.Message, "cannot unmarshal number into field spec.providerID")
+		})
+	})
+
+	t.Run("should report where malformed JSON breaks", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("POST", "/api/v1/nodes", strings.NewReader(`{"metadata": {"name": }}`))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+			var status api.Status
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+			assert.Contains(t, status.Message, "malformed JSON at offset")
+		})
+	})
+
 	t.Run("should return internal server error for registry failure", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -97,7 +204,6 @@ func TestCreateNode(t *testing.T) {
 		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
 			RegisterNodeRoutes(ws, handler)
 
-			mockStore.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("simulated registry failure"))
 			mockStore.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("simulated registry failure"))
 
 			node := &api.Node{
@@ -205,6 +311,36 @@ func TestGetNode(t *testing.T) {
 		})
 	})
 
+	t.Run("should return problem details when the client accepts them", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes/non-existent-node", nil)
+			// Successful responses are still JSON, so clients accept both
+			req.Header.Set("Accept", restful.MIME_JSON+", "+api.MIME_PROBLEM_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusNotFound, resp.Code)
+			assert.Equal(t, api.MIME_PROBLEM_JSON, resp.Header().Get("Content-Type"))
+
+			var problem api.Problem
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+			assert.Equal(t, api.Problem{
+				Type:     "https://gokube.io/problems/NotFound",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   registry.ErrNodeNotFound.Error(),
+				Instance: "/api/v1/nodes/non-existent-node",
+			}, problem)
+		})
+	})
+
 	t.Run("should return internal server error for registry failure", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -227,6 +363,59 @@ func TestGetNode(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, resp.Code)
 		})
 	})
+
+	t.Run("should return gateway timeout when the route timeout is exceeded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mockStorage.NewMockStorage(ctrl)
+		handler := NewNodeHandler(registry.NewNodeRegistry(mockStore))
+
+		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, handler, WithRouteTimeout("GET /nodes/{name}", 50*time.Millisecond))
+
+			mockStore.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, key string, obj runtime.Object) error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+			assert.Contains(t, resp.Body.String(), "/api/v1/nodes/{name}")
+		})
+	})
+}
+
+func TestNodeExists(t *testing.T) {
+	t.Run("should answer HEAD for existing and missing nodes", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+
+			RegisterNodeRoutes(ws, handler)
+
+			err := nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}})
+			require.NoError(t, err)
+
+			for name, wantStatus := range map[string]int{
+				"test-node":         http.StatusOK,
+				"non-existent-node": http.StatusNotFound,
+			} {
+				req := httptest.NewRequest("HEAD", "/api/v1/nodes/"+name, nil)
+				resp := httptest.NewRecorder()
+
+				container.ServeHTTP(resp, req)
+
+				assert.Equal(t, wantStatus, resp.Code, name)
+				assert.Empty(t, resp.Body.Bytes(), name)
+			}
+		})
+	})
 }
 
 func TestUpdateNode(t *testing.T) {
@@ -342,7 +531,7 @@ func TestUpdateNode(t *testing.T) {
 		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
 			RegisterNodeRoutes(ws, handler)
 
-			mockStore.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("simulated registry failure"))
+			mockStore.EXPECT().GetWithRevision(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("simulated registry failure"))
 
 			node := &api.Node{
 				ObjectMeta: api.ObjectMeta{
@@ -362,6 +551,36 @@ func TestUpdateNode(t *testing.T) {
 		})
 	})
 
+	t.Run("should report a lost update as a Conflict", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mockStorage.NewMockStorage(ctrl)
+		nodeRegistry := registry.NewNodeRegistry(mockStore)
+		handler := NewNodeHandler(nodeRegistry)
+
+		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, handler)
+
+			// Another writer gets in between every read and write
+			mockStore.EXPECT().GetWithRevision(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).AnyTimes()
+			mockStore.EXPECT().UpdateIfRevision(gomock.Any(), gomock.Any(), gomock.Any(), int64(1)).Return(storage.ErrConflict).AnyTimes()
+
+			body, _ := json.Marshal(&api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}})
+			req := httptest.NewRequest("PUT", "/api/v1/nodes/test-node", bytes.NewReader(body))
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			req.Header.Set("Accept", restful.MIME_JSON+", "+api.MIME_PROBLEM_JSON)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusConflict, resp.Code)
+			var problem api.Problem
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+			assert.Equal(t, "https://gokube.io/problems/Conflict", problem.Type)
+		})
+	})
+
 	t.Run("should return not found for non-existent node", func(t *testing.T) {
 		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
 			store := storage.NewEtcdStorage(etcdServer)
@@ -387,6 +606,101 @@ func TestUpdateNode(t *testing.T) {
 			assert.Equal(t, http.StatusNotFound, resp.Code)
 		})
 	})
+
+	t.Run("should honour If-Unmodified-Since", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+
+			RegisterNodeRoutes(ws, handler)
+
+			node := &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), node))
+
+			for since, wantStatus := range map[time.Time]int{
+				node.LastModified.Add(-time.Hour): http.StatusPreconditionFailed,
+				node.LastModified.Add(time.Hour):  http.StatusOK,
+			} {
+				body, _ := json.Marshal(&api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}, Status: api.NodeReady})
+				req := httptest.NewRequest("PUT", "/api/v1/nodes/test-node", bytes.NewReader(body))
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				req.Header.Set("If-Unmodified-Since", since.UTC().Format(http.TimeFormat))
+				resp := httptest.NewRecorder()
+
+				container.ServeHTTP(resp, req)
+
+				assert.Equal(t, wantStatus, resp.Code)
+				if wantStatus == http.StatusPreconditionFailed {
+					var status api.Status
+					require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+					assert.Equal(t, api.StatusReasonPreconditionFailed, status.Reason)
+				}
+			}
+
+			updated, err := nodeRegistry.GetNode(context.Background(), "test-node")
+			require.NoError(t, err)
+			assert.Equal(t, api.NodeReady, updated.Status)
+			assert.True(t, updated.LastModified.After(node.LastModified))
+		})
+	})
+	t.Run("should keep fields the body leaves out only with merge=true", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			ctx := context.Background()
+
+			for _, tc := range []struct {
+				query      string
+				wantLabels map[string]string
+			}{
+				{"?merge=true", map[string]string{"zone": "a"}},
+				{"", nil},
+			} {
+				name := "node" + strings.ReplaceAll(tc.query, "?merge=", "-")
+				require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{
+					ObjectMeta: api.ObjectMeta{Name: name, Labels: map[string]string{"zone": "a"}},
+				}))
+
+				body := fmt.Sprintf(`{"metadata":{"name":%q},"spec":{"unschedulable":true}}`, name)
+				req := httptest.NewRequest("PUT", "/api/v1/nodes/"+name+tc.query, strings.NewReader(body))
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				resp := httptest.NewRecorder()
+				container.ServeHTTP(resp, req)
+				require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+				updated, err := nodeRegistry.GetNode(ctx, name)
+				require.NoError(t, err)
+				assert.True(t, updated.Spec.Unschedulable)
+				assert.Equal(t, tc.wantLabels, updated.Labels, "query %q", tc.query)
+			}
+		})
+	})
+
+	t.Run("should reject an ambiguous merge parameter", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+
+			for _, header := range []string{"", time.Now().UTC().Format(http.TimeFormat)} {
+				query := "?merge=yes"
+				if header != "" {
+					query = "?merge=true"
+				}
+				req := httptest.NewRequest("PUT", "/api/v1/nodes/test-node"+query, strings.NewReader(`{"metadata":{"name":"test-node"}}`))
+				req.Header.Set("Content-Type", restful.MIME_JSON)
+				if header != "" {
+					req.Header.Set("If-Unmodified-Since", header)
+				}
+				resp := httptest.NewRecorder()
+				container.ServeHTTP(resp, req)
+
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Contains(t, resp.Body.String(), "merge=true")
+			}
+		})
+	})
 }
 
 func TestDeleteNode(t *testing.T) {
@@ -435,7 +749,7 @@ func TestDeleteNode(t *testing.T) {
 		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
 			RegisterNodeRoutes(ws, handler)
 
-			mockStore.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(errors.New("simulated registry failure"))
+			mockStore.EXPECT().GetAndDelete(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("simulated registry failure"))
 
 			req := httptest.NewRequest("DELETE", "/api/v1/nodes/test-node", nil)
 			resp := httptest.NewRecorder()
@@ -445,6 +759,25 @@ func TestDeleteNode(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, resp.Code)
 		})
 	})
+
+	t.Run("should return conflict when the registry reports one", func(t *testing.T) {
+		nodeRegistry := registry.NewFakeNodeRegistry()
+		nodeRegistry.AddReactor("DeleteNode", "test-node", func(registry.Action) (bool, any, error) {
+			return true, nil, registry.ErrNodeConflict
+		})
+		handler := NewNodeHandler(nodeRegistry)
+
+		withTestServer(t, func(_ *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("DELETE", "/api/v1/nodes/test-node", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusConflict, resp.Code)
+		})
+	})
 }
 func TestListNodes(t *testing.T) {
 	t.Run("should list all nodes", func(t *testing.T) {
@@ -493,6 +826,226 @@ func TestListNodes(t *testing.T) {
 		})
 	})
 
+	t.Run("should filter nodes by phase", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for name, status := range map[string]api.NodeStatus{
+				"ready-node":     api.NodeReady,
+				"not-ready-node": api.NodeNotReady,
+			} {
+				err := nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}, Status: status})
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?phase=NotReady", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var nodes []api.Node
+			err := json.Unmarshal(resp.Body.Bytes(), &nodes)
+			assert.NoError(t, err)
+			require.Len(t, nodes, 1)
+			assert.Equal(t, "not-ready-node", nodes[0].Name)
+			assert.Equal(t, api.NodePhaseNotReady, nodes[0].Phase())
+		})
+	})
+
+	t.Run("should exclude cordoned nodes when listing schedulable nodes", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, name := range []string{"cordoned-node", "ready-node"} {
+				err := nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}, Status: api.NodeReady})
+				require.NoError(t, err)
+			}
+			require.NoError(t, nodeRegistry.CordonNode(ctx, "cordoned-node", "maintenance", "ops"))
+
+			for _, path := range []string{"/api/v1/nodes?schedulable=true", "/api/v1/nodes?schedulable=true&phase=Ready"} {
+				req := httptest.NewRequest("GET", path, nil)
+				resp := httptest.NewRecorder()
+
+				container.ServeHTTP(resp, req)
+
+				require.Equal(t, http.StatusOK, resp.Code)
+
+				var nodes []api.Node
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+				require.Len(t, nodes, 1, path)
+				assert.Equal(t, "ready-node", nodes[0].Name)
+			}
+		})
+	})
+
+	t.Run("should return the intersection of label and field selectors", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, n := range []struct{ name, zone, pool string }{
+				{"zone-a-gpu", "a", "gpu"},
+				{"zone-a-cpu", "a", "cpu"},
+				{"zone-b-gpu", "b", "gpu"},
+			} {
+				node := &api.Node{ObjectMeta: api.ObjectMeta{Name: n.name, Labels: map[string]string{"zone": n.zone}}}
+				node.Spec.Pool = n.pool
+				require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?labelSelector=zone%3Da&fieldSelector=spec.pool%3Dgpu", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+			var nodes []api.Node
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+			require.Len(t, nodes, 1)
+			assert.Equal(t, "zone-a-gpu", nodes[0].Name)
+			assert.Equal(t, "1", resp.Header().Get("X-Filtered-Count"))
+		})
+	})
+
+	t.Run("should filter nodes by label and report counts", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for name, zone := range map[string]string{
+				"zone-a-node-1": "a",
+				"zone-a-node-2": "a",
+				"zone-b-node":   "b",
+			} {
+				node := &api.Node{ObjectMeta: api.ObjectMeta{Name: name, Labels: map[string]string{"zone": zone}}}
+				require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?labelSelector=zone%3Da", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, "3", resp.Header().Get(HeaderTotalCount))
+			assert.Equal(t, "2", resp.Header().Get(HeaderFilteredCount))
+
+			var nodes []api.Node
+			err := json.Unmarshal(resp.Body.Bytes(), &nodes)
+			assert.NoError(t, err)
+			require.Len(t, nodes, 2)
+			for _, node := range nodes {
+				assert.Equal(t, "a", node.Labels["zone"])
+			}
+		})
+	})
+
+	t.Run("should return bad request for a malformed label selector", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			handler := NewNodeHandler(registry.NewNodeRegistry(store))
+
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?labelSelector=%3Da", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+
+	t.Run("should clamp a huge limit and warn about it", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := registry.NewNodeRegistry(store)
+			handler := NewNodeHandler(nodeRegistry, WithPageSize(1, 2))
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, name := range []string{"test-node-1", "test-node-2", "test-node-3"} {
+				require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}))
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?limit=1000000", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Contains(t, resp.Header().Get(HeaderWarning), "limit 1000000 exceeds the maximum page size, using 2")
+			assert.Equal(t, "test-node-2", resp.Header().Get(HeaderContinue))
+
+			var nodes []api.Node
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+			require.Len(t, nodes, 2)
+
+			// A zero limit means the server default and continues where the last page ended
+			req = httptest.NewRequest("GET", "/api/v1/nodes?limit=0&continue=test-node-1", nil)
+			resp = httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Empty(t, resp.Header().Get(HeaderWarning))
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+			require.Len(t, nodes, 1)
+			assert.Equal(t, "test-node-2", nodes[0].Name)
+		})
+	})
+
+	t.Run("should return bad request for a negative limit", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?limit=-1", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+
+	t.Run("should return bad request for an unknown phase", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			store := storage.NewEtcdStorage(etcdServer)
+			handler := NewNodeHandler(registry.NewNodeRegistry(store))
+
+			RegisterNodeRoutes(ws, handler)
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?phase=Sleeping", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+
 	t.Run("should return internal server error for registry failure", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -516,3 +1069,179 @@ func TestListNodes(t *testing.T) {
 		})
 	})
 }
+
+func TestListNodesMaxPageBytes(t *testing.T) {
+	withLargeNodes := func(t *testing.T, maxBytes int, test func(container *restful.Container)) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry, WithMaxPageBytes(maxBytes)))
+			for _, name := range []string{"node-a", "node-b", "node-c"} {
+				node := &api.Node{ObjectMeta: api.ObjectMeta{Name: name, Annotations: map[string]string{"blob": strings.Repeat("x", 2000)}}}
+				require.NoError(t, nodeRegistry.CreateNode(context.Background(), node))
+			}
+			test(container)
+		})
+	}
+
+	t.Run("should cut a page short by size and return a continue token", func(t *testing.T) {
+		withLargeNodes(t, 5000, func(container *restful.Container) {
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes?limit=10", nil))
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.LessOrEqual(t, resp.Body.Len(), 5000+1)
+			var nodes []api.Node
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+			require.Len(t, nodes, 2)
+			assert.Equal(t, "node-b", resp.Header().Get(HeaderContinue))
+			assert.Contains(t, resp.Header().Get(HeaderWarning), "response size limit")
+
+			resp = httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes?limit=10&continue=node-b", nil))
+			require.Equal(t, http.StatusOK, resp.Code)
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+			require.Len(t, nodes, 1)
+			assert.Empty(t, resp.Header().Get(HeaderContinue))
+		})
+	})
+
+	t.Run("should reply 413 when a single node exceeds the limit", func(t *testing.T) {
+		withLargeNodes(t, 1000, func(container *restful.Container) {
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes", nil))
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		})
+	})
+}
+
+func TestListNodesLongPoll(t *testing.T) {
+	t.Run("should unblock when a node is created during the wait", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes", nil))
+			require.Equal(t, http.StatusOK, resp.Code)
+			rv := resp.Header().Get(HeaderResourceVersion)
+			require.NotEmpty(t, rv)
+
+			done := make(chan *httptest.ResponseRecorder)
+			go func() {
+				resp := httptest.NewRecorder()
+				container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes?wait=30s&resourceVersion="+rv, nil))
+				done <- resp
+			}()
+
+			select {
+			case <-done:
+				t.Fatal("long poll returned before anything changed")
+			case <-time.After(200 * time.Millisecond):
+			}
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+
+			select {
+			case resp := <-done:
+				require.Equal(t, http.StatusOK, resp.Code)
+				var nodes []api.Node
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &nodes))
+				require.Len(t, nodes, 1)
+				assert.Equal(t, "test-node", nodes[0].Name)
+				assert.NotEqual(t, rv, resp.Header().Get(HeaderResourceVersion))
+			case <-time.After(5 * time.Second):
+				t.Fatal("long poll didn't return after a node was created")
+			}
+		})
+	})
+
+	t.Run("should reply 304 when nothing changed before the timeout", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes?wait=100ms", nil))
+
+			assert.Equal(t, http.StatusNotModified, resp.Code)
+			assert.Empty(t, resp.Body.String())
+		})
+	})
+
+	t.Run("should reject an invalid wait", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes?wait=soon", nil))
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+}
+
+func TestStrictQueryParams(t *testing.T) {
+	serve := func(container *restful.Container, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject unknown query parameters in strict mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler, WithStrictQueryParams())
+
+			resp := serve(container, "/api/v1/nodes?labelSelectr=zone%3Da&limit=5&sort=name")
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+			var status api.Status
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+			assert.Contains(t, status.Message, "unknown query parameters: labelSelectr, sort")
+
+			resp = serve(container, "/api/v1/nodes?labelSelector=zone%3Da&limit=5")
+			assert.Equal(t, http.StatusOK, resp.Code)
+
+			resp = serve(container, "/api/v1/nodes/test-node?limit=5")
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+	})
+
+	t.Run("should ignore unknown query parameters in lenient mode", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			handler := NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer)))
+			RegisterNodeRoutes(ws, handler)
+
+			resp := serve(container, "/api/v1/nodes?labelSelectr=zone%3Da")
+			assert.Equal(t, http.StatusOK, resp.Code)
+		})
+	})
+}
+
+func TestGetPoolSummary(t *testing.T) {
+	t.Run("should summarise the nodes in a pool", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, name := range []string{"gpu-1", "gpu-2"} {
+				node := &api.Node{ObjectMeta: api.ObjectMeta{Name: name}, Spec: api.NodeSpec{Pool: "gpu"}, Status: api.NodeReady}
+				require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/nodepools/gpu/summary", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var summary registry.PoolSummary
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &summary))
+			assert.Equal(t, registry.PoolSummary{Pool: "gpu", Nodes: 2, Ready: 2, Schedulable: 2}, summary)
+		})
+	})
+}
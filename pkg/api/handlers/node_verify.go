@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// RegisterAdminNodeRoutes registers the Node routes reserved for operators with
+// ws, which is expected to be rooted at /admin and to only let operators through
+func RegisterAdminNodeRoutes(ws *restful.WebService, handler *NodeHandler) {
+	ws.Route(ws.GET("/nodes:verify").To(handler.VerifyNodes))
+}
+
+// VerifyNodes handles GET requests to check every stored Node key and report the
+// ones that don't hold a valid Node. It only reads, so it is safe to run at any time,
+// but it scans the whole store and its report shows raw stored values, so it is an
+// admin route.
+func (h *NodeHandler) VerifyNodes(request *restful.Request, response *restful.Response) {
+	report, err := h.nodeRegistry.VerifyStore(request.Request.Context())
+	h.handleNodeResponse(request, response, http.StatusOK, report, err)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestVerifyNodes(t *testing.T) {
+	t.Run("should report corrupted node values", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			ctx := context.Background()
+
+			handler := NewNodeHandler(nodeRegistry)
+			RegisterNodeRoutes(ws, handler)
+			admin := new(restful.WebService).Path("/admin").Produces(restful.MIME_JSON)
+			RegisterAdminNodeRoutes(admin, handler)
+			container.Add(admin)
+
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+			_, err := etcdServer.Put(ctx, "/registry/nodes/corrupt", "not json")
+			require.NoError(t, err)
+
+			// The scan is only served to operators, under /admin
+			resp := httptest.NewRecorder()
+			container.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/nodes:verify", nil))
+			assert.Equal(t, http.StatusNotFound, resp.Code)
+
+			req := httptest.NewRequest("GET", "/admin/nodes:verify", nil)
+			resp = httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			var report registry.VerifyReport
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+			assert.Equal(t, 2, report.Checked)
+			require.Len(t, report.Invalid, 1)
+			assert.Equal(t, "/registry/nodes/corrupt", report.Invalid[0].Key)
+		})
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+	"gokube/pkg/registry"
+	"gokube/pkg/watch"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// watchableEventTypes are the event types a watch can be limited to with
+// ?eventTypes; Bookmarks are always sent
+var watchableEventTypes = []watch.EventType{watch.Added, watch.Modified, watch.Deleted}
+
+// eventTypesParam parses ?eventTypes, a comma-separated list of event types matched
+// case-insensitively, e.g. Deleted,Added. It returns nil, meaning all types, if the
+// parameter isn't set.
+func eventTypesParam(request *restful.Request) (map[watch.EventType]bool, error) {
+	param := request.QueryParameter("eventTypes")
+	if param == "" {
+		return nil, nil
+	}
+
+	eventTypes := map[watch.EventType]bool{}
+	for _, name := range strings.Split(param, ",") {
+		eventType := watch.EventType(strings.ToUpper(strings.TrimSpace(name)))
+		if !slices.Contains(watchableEventTypes, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q in eventTypes, must be one of Added, Modified, Deleted", registry.ErrNodeInvalid, name)
+		}
+		eventTypes[eventType] = true
+	}
+	return eventTypes, nil
+}
+
+// watchNodes streams watch events for Nodes matching filter as newline-delimited JSON
+// until the client goes away. With sendInitial, the stream starts with an Added event
+// for every existing Node followed by a Bookmark. Only events of eventTypes are
+// sent, unless it is nil. Heartbeat Bookmarks are sent as configured with
+// WithStreamTimeouts.
+func (h *NodeHandler) watchNodes(request *restful.Request, response *restful.Response, filter registry.NodeFilter, sendInitial bool, eventTypes map[watch.EventType]bool) {
+	ctx := request.Request.Context()
+
+	var w watch.Interface
+	var err error
+	if sendInitial {
+		w, err = h.nodeRegistry.WatchNodesWithInitialEvents(ctx)
+	} else {
+		w, err = h.nodeRegistry.WatchNodes(ctx)
+	}
+	if err != nil {
+		h.handleNodeResponse(request, response, http.StatusOK, nil, err)
+		return
+	}
+	h.streamWatch(request, response, w, filter, eventTypes)
+}
+
+// WatchNode handles GET requests to watch a single Node. The stream starts with an
+// Added event with the current state of the Node, or a Deleted event if it doesn't
+// exist, and is otherwise sent like that of ListNodes with ?watch=true.
+func (h *NodeHandler) WatchNode(request *restful.Request, response *restful.Response) {
+	w, err := h.nodeRegistry.WatchNode(request.Request.Context(), request.PathParameter("name"))
+	if err != nil {
+		h.handleNodeResponse(request, response, http.StatusOK, nil, err)
+		return
+	}
+	h.streamWatch(request, response, w, registry.NodeFilter{}, nil)
+}
+
+// streamWatch sends the events of w for Nodes matching filter, and of eventTypes
+// unless it is nil, as newline-delimited JSON until w ends or the client goes away
+func (h *NodeHandler) streamWatch(request *restful.Request, response *restful.Response, w watch.Interface, filter registry.NodeFilter, eventTypes map[watch.EventType]bool) {
+	defer w.Stop()
+
+	// Send the header right away so clients know the watch is established
+	out := h.streamWriter(request, response)
+	out.writeHeader()
+	response.Flush()
+
+	var heartbeat <-chan time.Time
+	if h.watchHeartbeat > 0 {
+		ticker := time.NewTicker(h.watchHeartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	encoder := json.NewEncoder(out)
+	for {
+		var event watch.Event
+		select {
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			event = e
+		case <-heartbeat:
+			// Clients already skip Bookmarks they don't care about
+			event = watch.Event{Type: watch.Bookmark, Object: &api.Node{}}
+		}
+
+		if node, ok := event.Object.(*api.Node); ok {
+			if event.Type != watch.Bookmark && (!filter.Matches(node) || (eventTypes != nil && !eventTypes[event.Type])) {
+				continue
+			}
+			event.Object = v1.ConvertFromInternal(node)
+		}
+
+		if err := encoder.Encode(event); err != nil {
+			log.Printf("Error writing watch event: %v", err)
+			return
+		}
+	}
+}
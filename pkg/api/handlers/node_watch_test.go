@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+	"gokube/pkg/watch"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// watchEvent is a watch.Event as it appears on the wire
+type watchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object api.Node        `json:"object"`
+}
+
+func TestWatchNodes(t *testing.T) {
+	t.Run("should send initial events and a bookmark before live events", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			handler := NewNodeHandler(nodeRegistry)
+			ctx := context.Background()
+
+			RegisterNodeRoutes(ws, handler)
+
+			for _, name := range []string{"test-node-1", "test-node-2"} {
+				require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}))
+			}
+
+			server := httptest.NewServer(container)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/v1/nodes?watch=true&sendInitialEvents=true")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			reader := bufio.NewReader(resp.Body)
+			next := func() watchEvent {
+				line, err := reader.ReadBytes('\n')
+				require.NoError(t, err)
+				var event watchEvent
+				require.NoError(t, json.Unmarshal(line, &event))
+				return event
+			}
+
+			var initial []string
+			for i := 0; i < 2; i++ {
+				event := next()
+				assert.Equal(t, watch.Added, event.Type)
+				initial = append(initial, event.Object.Name)
+			}
+			assert.ElementsMatch(t, []string{"test-node-1", "test-node-2"}, initial)
+
+			bookmark := next()
+			assert.Equal(t, watch.Bookmark, bookmark.Type)
+			assert.Equal(t, "true", bookmark.Object.Annotations[watch.InitialEventsEndAnnotation])
+
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-3"}}))
+			live := next()
+			assert.Equal(t, watch.Added, live.Type)
+			assert.Equal(t, "test-node-3", live.Object.Name)
+		})
+	})
+
+	t.Run("should keep a watch open past the write timeout with heartbeats", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry, WithStreamTimeouts(50*time.Millisecond, 0)))
+
+			// Without heartbeats and deadline handling, the write timeout would end the
+			// stream after 200ms
+			server := httptest.NewUnstartedServer(container)
+			server.Config.WriteTimeout = 200 * time.Millisecond
+			server.Start()
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/v1/nodes?watch=true")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			reader := bufio.NewReader(resp.Body)
+			deadline := time.Now().Add(time.Second)
+			heartbeats := 0
+			for time.Now().Before(deadline) {
+				line, err := reader.ReadBytes('\n')
+				require.NoError(t, err, "stream closed after %d heartbeats", heartbeats)
+				var event watchEvent
+				require.NoError(t, json.Unmarshal(line, &event))
+				assert.Equal(t, watch.Bookmark, event.Type)
+				heartbeats++
+			}
+			assert.Greater(t, heartbeats, 5)
+
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+			for {
+				line, err := reader.ReadBytes('\n')
+				require.NoError(t, err)
+				var event watchEvent
+				require.NoError(t, json.Unmarshal(line, &event))
+				if event.Type != watch.Bookmark {
+					assert.Equal(t, watch.Added, event.Type)
+					assert.Equal(t, "test-node", event.Object.Name)
+					break
+				}
+			}
+		})
+	})
+
+	t.Run("should only send the requested event types", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			ctx := context.Background()
+
+			server := httptest.NewServer(container)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/v1/nodes?watch=true&eventTypes=Deleted")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+			require.NoError(t, nodeRegistry.DeleteNode(ctx, "test-node"))
+
+			// The Added event was dropped, so the first event is the deletion
+			line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+			require.NoError(t, err)
+			var event watchEvent
+			require.NoError(t, json.Unmarshal(line, &event))
+			assert.Equal(t, watch.Deleted, event.Type)
+			assert.Equal(t, "test-node", event.Object.Name)
+		})
+	})
+
+	t.Run("should reject unknown event types", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			RegisterNodeRoutes(ws, NewNodeHandler(registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))))
+
+			req := httptest.NewRequest("GET", "/api/v1/nodes?watch=true&eventTypes=Deleted,Removed", nil)
+			resp := httptest.NewRecorder()
+
+			container.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			assert.Contains(t, resp.Body.String(), `unknown event type \"Removed\"`)
+		})
+	})
+}
+
+func TestWatchNode(t *testing.T) {
+	t.Run("should only send events for the watched node", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+			ctx := context.Background()
+
+			for _, name := range []string{"test-node-1", "test-node-2"} {
+				require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}))
+			}
+
+			server := httptest.NewServer(container)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/v1/nodes/test-node-1/watch")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			reader := bufio.NewReader(resp.Body)
+			next := func() watchEvent {
+				line, err := reader.ReadBytes('\n')
+				require.NoError(t, err)
+				var event watchEvent
+				require.NoError(t, json.Unmarshal(line, &event))
+				return event
+			}
+
+			initial := next()
+			assert.Equal(t, watch.Added, initial.Type)
+			assert.Equal(t, "test-node-1", initial.Object.Name)
+
+			update := func(name, pool string) {
+				require.NoError(t, nodeRegistry.UpdateNodeWithRetry(ctx, name, func(node *api.Node) error {
+					node.Spec.Pool = pool
+					return nil
+				}))
+			}
+			// Events are sent in order, so the other node's update would come first
+			update("test-node-2", "other")
+			update("test-node-1", "watched")
+
+			event := next()
+			assert.Equal(t, watch.Modified, event.Type)
+			assert.Equal(t, "test-node-1", event.Object.Name)
+			assert.Equal(t, "watched", event.Object.Spec.Pool)
+		})
+	})
+
+	t.Run("should start with a deletion if the node doesn't exist", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			server := httptest.NewServer(container)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/v1/nodes/test-node/watch")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			reader := bufio.NewReader(resp.Body)
+			line, err := reader.ReadBytes('\n')
+			require.NoError(t, err)
+			var event watchEvent
+			require.NoError(t, json.Unmarshal(line, &event))
+			assert.Equal(t, watch.Deleted, event.Type)
+			assert.Equal(t, "test-node", event.Object.Name)
+
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+			line, err = reader.ReadBytes('\n')
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(line, &event))
+			assert.Equal(t, watch.Added, event.Type)
+		})
+	})
+
+	t.Run("should stop the watch when the client goes away", func(t *testing.T) {
+		withTestServer(t, func(etcdServer *clientv3.Client, ws *restful.WebService, container *restful.Container) {
+			nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			RegisterNodeRoutes(ws, NewNodeHandler(nodeRegistry))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequest("GET", "/api/v1/nodes/test-node/watch", nil).WithContext(ctx)
+			resp := httptest.NewRecorder()
+
+			done := make(chan struct{})
+			go func() {
+				container.ServeHTTP(resp, req)
+				close(done)
+			}()
+			cancel()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("watch did not stop after the context was cancelled")
+			}
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// NodeUserPrefix starts the common name of client certificates issued to nodes,
+// followed by the node name
+const NodeUserPrefix = "system:node:"
+
+// OperatorGroup is the organization of client certificates issued to operators,
+// the only identities allowed to use the admin endpoints
+const OperatorGroup = "system:masters"
+
+// NodeIdentity returns the name of the node that sent r and whether r was sent by a
+// node at all. The name comes from the common name of a verified TLS client
+// certificate, so the server's TLSConfig must request and verify client
+// certificates for any request to carry a node identity.
+func NodeIdentity(r *http.Request) (string, bool) {
+	cert, ok := clientCertificate(r)
+	if !ok {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(cert.Subject.CommonName, NodeUserPrefix)
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// IsOperator reports whether r was sent with a verified client certificate in
+// OperatorGroup. A node certificate never counts, whatever its organization.
+func IsOperator(r *http.Request) bool {
+	if _, ok := NodeIdentity(r); ok {
+		return false
+	}
+	cert, ok := clientCertificate(r)
+	return ok && slices.Contains(cert.Subject.Organization, OperatorGroup)
+}
+
+// OperatorFilter only lets operators through, see IsOperator. Requests without
+// any credentials get 401, those of nodes and other identities 403.
+func OperatorFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if IsOperator(req.Request) {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+	if _, ok := clientCertificate(req.Request); !ok && req.HeaderParameter("Authorization") == "" {
+		WriteError(req, resp, http.StatusUnauthorized, errors.New("this endpoint requires an operator client certificate"))
+		return
+	}
+	WriteError(req, resp, http.StatusForbidden, errors.New("this endpoint is only available to operators"))
+}
+
+// clientCertificate returns the verified client certificate r was sent with
+func clientCertificate(r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return r.TLS.VerifiedChains[0][0], true
+}
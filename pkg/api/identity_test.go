@@ -0,0 +1,69 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeIdentity(t *testing.T) {
+	withCert := func(commonName string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	tests := []struct {
+		name     string
+		tls      *tls.ConnectionState
+		wantName string
+		wantOK   bool
+	}{
+		{name: "node certificate", tls: withCert("system:node:node-1"), wantName: "node-1", wantOK: true},
+		{name: "other certificate", tls: withCert("admin")},
+		{name: "empty node name", tls: withCert(NodeUserPrefix)},
+		{name: "unverified", tls: &tls.ConnectionState{}},
+		{name: "plain HTTP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/api/v1/nodes/self", nil)
+			req.TLS = tt.tls
+
+			name, ok := NodeIdentity(req)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestIsOperator(t *testing.T) {
+	withCert := func(commonName string, organizations ...string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, Organization: organizations}}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	tests := []struct {
+		name string
+		tls  *tls.ConnectionState
+		want bool
+	}{
+		{name: "operator certificate", tls: withCert("alice", "dev", OperatorGroup), want: true},
+		{name: "node certificate in the operator group", tls: withCert(NodeUserPrefix+"node-1", OperatorGroup)},
+		{name: "other certificate", tls: withCert("alice", "dev")},
+		{name: "plain HTTP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/admin/readonly", nil)
+			req.TLS = tt.tls
+
+			assert.Equal(t, tt.want, IsOperator(req))
+		})
+	}
+}
@@ -4,9 +4,12 @@ import "github.com/go-playground/validator/v10"
 
 // Node is a simplified representation of a Kubernetes Node
 type Node struct {
+	TypeMeta
 	ObjectMeta `json:"metadata,omitempty"`
 	Spec       NodeSpec   `json:"spec,omitempty"`
 	Status     NodeStatus `json:"status,omitempty"`
+	// Info is reported by the node's agent and is nil until it has done so
+	Info *NodeInfo `json:"nodeInfo,omitempty"`
 }
 
 // Validate checks if the Node configuration is valid
@@ -16,5 +19,12 @@ func (n *Node) Validate() error {
 		return ErrInvalidNodeSpec
 	}
 
-	return nil
+	if err := validateLabels(n.Labels); err != nil {
+		return err
+	}
+	if err := validateNodeInfo(n.Info); err != nil {
+		return err
+	}
+
+	return validateAnnotations(n.Annotations)
 }
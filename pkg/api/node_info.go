@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NodeInfo is what the agent on a node reports about the software it runs
+type NodeInfo struct {
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+	// ContainerRuntimeVersion is written as <runtime>://<version>, e.g.
+	// "containerd://1.7.2"
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion,omitempty"`
+	OSImage                 string `json:"osImage,omitempty"`
+	KernelVersion           string `json:"kernelVersion,omitempty"`
+}
+
+// versionRegexp matches semver-ish versions such as "v1.30.2" or "1.7.2-rc.1+abc";
+// the patch number may be left out
+var versionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// Version is a parsed semver-ish version
+type Version struct {
+	Major, Minor, Patch int
+	// PreRelease is the part after "-", a version with one sorts before the release
+	PreRelease string
+}
+
+// ParseVersion parses a semver-ish version string. Build metadata after "+" is ignored.
+func ParseVersion(s string) (Version, error) {
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("%q is not a version of the form [v]MAJOR.MINOR[.PATCH]", s)
+	}
+
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	v.PreRelease = m[4]
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 if v is older than, the same as or newer than other
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+
+	switch {
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	default:
+		return strings.Compare(v.PreRelease, other.PreRelease)
+	}
+}
+
+// validateNodeInfo checks that the reported versions parse. OS image and kernel
+// version are free-form.
+func validateNodeInfo(info *NodeInfo) error {
+	if info == nil {
+		return nil
+	}
+
+	if info.KubeletVersion != "" {
+		if _, err := ParseVersion(info.KubeletVersion); err != nil {
+			return FieldError("nodeInfo.kubeletVersion", "%v", err)
+		}
+	}
+	if info.ContainerRuntimeVersion != "" {
+		runtime, version, ok := strings.Cut(info.ContainerRuntimeVersion, "://")
+		if !ok || runtime == "" {
+			return FieldError("nodeInfo.containerRuntimeVersion", "must be of the form <runtime>://<version>")
+		}
+		if _, err := ParseVersion(version); err != nil {
+			return FieldError("nodeInfo.containerRuntimeVersion", "%v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	t.Run("should order versions", func(t *testing.T) {
+		ordered := []string{"1.28", "v1.29.0-rc.1", "v1.29.0", "1.29.3+build.5", "v1.30.0", "2.0.0"}
+		for i := 1; i < len(ordered); i++ {
+			older, err := ParseVersion(ordered[i-1])
+			require.NoError(t, err)
+			newer, err := ParseVersion(ordered[i])
+			require.NoError(t, err)
+
+			assert.Equal(t, -1, older.Compare(newer), "%s < %s", ordered[i-1], ordered[i])
+			assert.Equal(t, 1, newer.Compare(older), "%s > %s", ordered[i], ordered[i-1])
+		}
+
+		a, _ := ParseVersion("v1.29.0+a")
+		b, _ := ParseVersion("1.29.0+b")
+		assert.Equal(t, 0, a.Compare(b))
+	})
+
+	t.Run("should reject things that aren't versions", func(t *testing.T) {
+		for _, s := range []string{"", "1", "latest", "v1.x.0", "1.2.3.4"} {
+			_, err := ParseVersion(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestNodeInfoValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    *NodeInfo
+		wantErr string
+	}{
+		{name: "no info"},
+		{
+			name: "valid info",
+			info: &NodeInfo{KubeletVersion: "v1.30.2", ContainerRuntimeVersion: "containerd://1.7.2", OSImage: "Ubuntu 24.04 LTS"},
+		},
+		{
+			name:    "bad kubelet version",
+			info:    &NodeInfo{KubeletVersion: "latest"},
+			wantErr: "nodeInfo.kubeletVersion",
+		},
+		{
+			name:    "runtime version without runtime",
+			info:    &NodeInfo{ContainerRuntimeVersion: "1.7.2"},
+			wantErr: "nodeInfo.containerRuntimeVersion: must be of the form <runtime>://<version>",
+		},
+		{
+			name:    "bad runtime version",
+			info:    &NodeInfo{ContainerRuntimeVersion: "containerd://seven"},
+			wantErr: "nodeInfo.containerRuntimeVersion",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &Node{ObjectMeta: ObjectMeta{Name: "test-node"}, Info: tt.info}
+			err := node.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidNodeSpec)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
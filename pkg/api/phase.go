@@ -0,0 +1,43 @@
+package api
+
+// NodePhase is a coarse summary of where a node is in its lifecycle
+type NodePhase string
+
+const (
+	// NodePending means the node has registered but not reported a status yet
+	NodePending NodePhase = "Pending"
+	// NodePhaseReady means the node reported Ready
+	NodePhaseReady NodePhase = "Ready"
+	// NodePhaseNotReady means the node reported any status other than Ready
+	NodePhaseNotReady NodePhase = "NotReady"
+)
+
+// Phase computes the node's phase from its status. This is the single place the
+// mapping is defined; callers should not compare Status values themselves.
+func (n *Node) Phase() NodePhase {
+	switch n.Status {
+	case "", NodeUnknown:
+		return NodePending
+	case NodeReady:
+		return NodePhaseReady
+	default:
+		return NodePhaseNotReady
+	}
+}
+
+// Schedulable reports whether new work may be placed on the node: it must be Ready
+// and not cordoned. Every path that reports schedulable nodes goes through this, so
+// a cordoned node is excluded from all of them alike.
+func (n *Node) Schedulable() bool {
+	return n.Phase() == NodePhaseReady && !n.Spec.Unschedulable
+}
+
+// ParseNodePhase returns the NodePhase named by s and whether it is a known phase
+func ParseNodePhase(s string) (NodePhase, bool) {
+	switch phase := NodePhase(s); phase {
+	case NodePending, NodePhaseReady, NodePhaseNotReady:
+		return phase, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodePhase(t *testing.T) {
+	tests := []struct {
+		status NodeStatus
+		want   NodePhase
+	}{
+		{status: "", want: NodePending},
+		{status: NodeUnknown, want: NodePending},
+		{status: NodeReady, want: NodePhaseReady},
+		{status: NodeNotReady, want: NodePhaseNotReady},
+		{status: NodeMemoryPressure, want: NodePhaseNotReady},
+		{status: NodeDiskPressure, want: NodePhaseNotReady},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			node := &Node{Status: tt.status}
+			assert.Equal(t, tt.want, node.Phase())
+		})
+	}
+}
+
+func TestNodeSchedulable(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        NodeStatus
+		unschedulable bool
+		want          bool
+	}{
+		{name: "ready", status: NodeReady, want: true},
+		{name: "cordoned", status: NodeReady, unschedulable: true, want: false},
+		{name: "not ready", status: NodeNotReady, want: false},
+		{name: "pending", status: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &Node{Status: tt.status, Spec: NodeSpec{Unschedulable: tt.unschedulable}}
+			assert.Equal(t, tt.want, node.Schedulable())
+		})
+	}
+}
+
+func TestParseNodePhase(t *testing.T) {
+	phase, ok := ParseNodePhase("NotReady")
+	assert.True(t, ok)
+	assert.Equal(t, NodePhaseNotReady, phase)
+
+	_, ok = ParseNodePhase("Terminating")
+	assert.False(t, ok)
+}
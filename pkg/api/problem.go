@@ -0,0 +1,64 @@
+package api
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_PROBLEM_JSON is the content type of RFC 7807 problem details
+const MIME_PROBLEM_JSON = "application/problem+json"
+
+// problemTypeBase prefixes the reason of a failure to form its problem type URI
+const problemTypeBase = "https://gokube.io/problems/"
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// problemType returns the type URI for a failure with the given reason. Failures
+// without a more specific reason use about:blank, as RFC 7807 recommends.
+func problemType(reason StatusReason) string {
+	if reason == StatusReasonUnknown {
+		return "about:blank"
+	}
+	return problemTypeBase + string(reason)
+}
+
+// acceptsProblemJSON reports whether the request lists problem+json in its Accept
+// header. Routes produce JSON, so clients have to accept application/json as well
+// or content negotiation fails with 406 before the handler runs.
+func acceptsProblemJSON(request *restful.Request) bool {
+	if request == nil || request.Request == nil {
+		return false
+	}
+	for _, accept := range strings.Split(request.Request.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == MIME_PROBLEM_JSON {
+			return true
+		}
+	}
+	return false
+}
+
+func writeProblem(request *restful.Request, response *restful.Response, status int, reason StatusReason, err error) {
+	body := &Problem{
+		Type:     problemType(reason),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: request.Request.URL.RequestURI(),
+		Detail:   errorMessage(request, response, status, err),
+	}
+
+	if writeErr := response.WriteHeaderAndJson(status, body, MIME_PROBLEM_JSON); writeErr != nil {
+		log.Printf("Error writing error response: %v", writeErr)
+	}
+}
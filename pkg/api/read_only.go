@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// ErrReadOnly is returned for writes while the server is in read-only mode
+var ErrReadOnly = errors.New("the server is in read-only mode, writes are disabled")
+
+// ReadOnlyMode freezes writes during maintenance while reads, lists and watches are
+// still served. It can be switched on and off while the server is running.
+type ReadOnlyMode struct {
+	enabled atomic.Bool
+}
+
+// NewReadOnlyMode returns a ReadOnlyMode that starts out enabled or not
+func NewReadOnlyMode(enabled bool) *ReadOnlyMode {
+	m := &ReadOnlyMode{}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled reports whether writes are currently rejected
+func (m *ReadOnlyMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled switches read-only mode on or off
+func (m *ReadOnlyMode) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Filter rejects every request other than GET, HEAD and OPTIONS with 503 while
+// read-only mode is enabled
+func (m *ReadOnlyMode) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	switch req.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if m.Enabled() {
+			WriteError(req, resp, http.StatusServiceUnavailable, ErrReadOnly)
+			return
+		}
+	}
+	chain.ProcessFilter(req, resp)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	mode := NewReadOnlyMode(true)
+	ws := new(restful.WebService)
+	ws.Path("/api/v1").Produces(restful.MIME_JSON).Filter(mode.Filter)
+	echo := func(request *restful.Request, response *restful.Response) {
+		WriteResponse(response, http.StatusOK, nil)
+	}
+	ws.Route(ws.GET("/nodes").To(echo))
+	ws.Route(ws.POST("/nodes").To(echo))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/nodes", nil)
+		resp := httptest.NewRecorder()
+		container.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject writes with 503 while enabled", func(t *testing.T) {
+		resp := serve("POST")
+
+		require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		var status Status
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		assert.Equal(t, StatusReasonServiceUnavailable, status.Reason)
+		assert.Contains(t, status.Message, "read-only")
+	})
+
+	t.Run("should keep serving reads while enabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("GET").Code)
+	})
+
+	t.Run("should accept writes again once disabled", func(t *testing.T) {
+		mode.SetEnabled(false)
+		defer mode.SetEnabled(true)
+
+		assert.Equal(t, http.StatusOK, serve("POST").Code)
+	})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// HeaderRequestID carries the ID that correlates a request across logs and responses
+const HeaderRequestID = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID stored in ctx, or "" if there is none
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestIDFilter stores the incoming X-Request-Id, or a newly generated one, in
+// the request context and echoes it back on the response
+func RequestIDFilter(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	requestID := request.Request.Header.Get(HeaderRequestID)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	request.Request = request.Request.WithContext(WithRequestID(request.Request.Context(), requestID))
+	response.Header().Set(HeaderRequestID, requestID)
+
+	chain.ProcessFilter(request, response)
+}
+
+// AccessLogFilter logs one line per request once it has been served
+func AccessLogFilter(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	start := time.Now()
+	chain.ProcessFilter(request, response)
+
+	log.Printf("%s %s %d %s request_id=%s",
+		request.Request.Method,
+		request.Request.URL.RequestURI(),
+		response.StatusCode(),
+		time.Since(start),
+		RequestIDFrom(request.Request.Context()),
+	)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Error generating request ID: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDFilter(t *testing.T) {
+	newContainer := func(handler restful.RouteFunction) *restful.Container {
+		container := restful.NewContainer()
+		container.Filter(RequestIDFilter)
+		container.Filter(AccessLogFilter)
+
+		ws := new(restful.WebService)
+		ws.Path("/api/v1").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+		ws.Route(ws.GET("/nodes/{name}").To(handler))
+		container.Add(ws)
+		return container
+	}
+
+	t.Run("should include the provided request ID in the error body and access log", func(t *testing.T) {
+		var logs bytes.Buffer
+		log.SetOutput(&logs)
+		defer log.SetOutput(os.Stderr)
+
+		var ctxRequestID string
+		container := newContainer(func(request *restful.Request, response *restful.Response) {
+			ctxRequestID = RequestIDFrom(request.Request.Context())
+			WriteError(request, response, http.StatusNotFound, errors.New("node not found"))
+		})
+
+		req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+		req.Header.Set(HeaderRequestID, "req-123")
+		resp := httptest.NewRecorder()
+
+		container.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Equal(t, "req-123", resp.Header().Get(HeaderRequestID))
+		assert.Equal(t, "req-123", ctxRequestID)
+
+		var status Status
+		err := json.Unmarshal(resp.Body.Bytes(), &status)
+		require.NoError(t, err)
+		assert.Equal(t, Status{
+			Status:    StatusFailure,
+			Message:   "node not found",
+			Reason:    StatusReasonNotFound,
+			Code:      http.StatusNotFound,
+			RequestID: "req-123",
+		}, status)
+
+		assert.Contains(t, logs.String(), "GET /api/v1/nodes/test-node 404")
+		assert.Contains(t, logs.String(), "request_id=req-123")
+	})
+
+	t.Run("should generate a request ID when none is provided", func(t *testing.T) {
+		var ctxRequestID string
+		container := newContainer(func(request *restful.Request, response *restful.Response) {
+			ctxRequestID = RequestIDFrom(request.Request.Context())
+			WriteResponse(response, http.StatusOK, nil)
+		})
+
+		req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+		resp := httptest.NewRecorder()
+
+		container.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Len(t, ctxRequestID, 32)
+		assert.Equal(t, ctxRequestID, resp.Header().Get(HeaderRequestID))
+	})
+}
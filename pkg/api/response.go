@@ -1,7 +1,10 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -18,9 +21,62 @@ func WriteResponse(response *restful.Response, status int, entity interface{}) {
 	response.WriteHeader(status)
 }
 
-// WriteError is a helper function to write an error response
-func WriteError(response *restful.Response, status int, err error) {
-	if writeErr := response.WriteError(status, err); writeErr != nil {
+// WriteError is a helper function to write an error response as a Status, or as
+// RFC 7807 problem details if the request accepts application/problem+json. The
+// reason is the one that goes with status, see WriteErrorWithReason.
+func WriteError(request *restful.Request, response *restful.Response, status int, err error) {
+	WriteErrorWithReason(request, response, status, StatusReasonUnknown, err)
+}
+
+// WriteErrorWithReason is WriteError for callers that know why err happened more
+// precisely than status says, e.g. a 409 that is a Conflict rather than the
+// AlreadyExists every other 409 is. An unknown reason falls back to the one that
+// goes with status.
+func WriteErrorWithReason(request *restful.Request, response *restful.Response, status int, reason StatusReason, err error) {
+	if reason == StatusReasonUnknown {
+		reason = reasonForCode(status)
+	}
+	if acceptsProblemJSON(request) {
+		writeProblem(request, response, status, reason, err)
+		return
+	}
+
+	body := &Status{
+		Status:    StatusFailure,
+		Reason:    reason,
+		Code:      status,
+		RequestID: response.Header().Get(HeaderRequestID),
+		Message:   errorMessage(request, response, status, err),
+	}
+
+	if writeErr := response.WriteHeaderAndJson(status, body, restful.MIME_JSON); writeErr != nil {
 		log.Printf("Error writing error response: %v", writeErr)
 	}
 }
+
+// WriteServiceError writes the errors go-restful raises while selecting a route,
+// such as 405 or 415, as a Status like any other error. Headers that come with the
+// error, e.g. the Allow header of a 405, are kept.
+func WriteServiceError(serviceErr restful.ServiceError, request *restful.Request, response *restful.Response) {
+	for header, values := range serviceErr.Header {
+		for _, value := range values {
+			response.Header().Add(header, value)
+		}
+	}
+	if serviceErr.Code == http.StatusNotFound {
+		WriteError(request, response, serviceErr.Code, pathNotFound(request.Request))
+		return
+	}
+	WriteError(request, response, serviceErr.Code, errors.New(serviceErr.Message))
+}
+
+// NotFound replies to requests that match no route at all with a NotFound Status
+// naming the requested path. It is meant to be registered for "/" so paths outside
+// the API get the same error format as unknown paths inside it.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteError(restful.NewRequest(r), restful.NewResponse(w), http.StatusNotFound, pathNotFound(r))
+}
+
+func pathNotFound(r *http.Request) error {
+	return fmt.Errorf("the server could not find the requested path %q", r.URL.Path)
+}
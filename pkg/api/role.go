@@ -0,0 +1,25 @@
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+// LabelNodeRolePrefix prefixes the labels that give a node its roles, e.g.
+// node-role.kubernetes.io/control-plane
+const LabelNodeRolePrefix = "node-role.kubernetes.io/"
+
+// NodeRoleControlPlane is the role of nodes that run the control plane
+const NodeRoleControlPlane = "control-plane"
+
+// Roles returns the node's roles, from its LabelNodeRolePrefix labels, sorted
+func (n *Node) Roles() []string {
+	var roles []string
+	for key := range n.Labels {
+		if role, ok := strings.CutPrefix(key, LabelNodeRolePrefix); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
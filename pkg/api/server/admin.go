@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"gokube/pkg/api"
+	"gokube/pkg/api/handlers"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// ReadOnlyState is the body of the /admin/readonly endpoint
+type ReadOnlyState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// BootstrapTokenStore authenticates bootstrap tokens and stores new ones, see
+// registry.BootstrapTokenRegistry
+type BootstrapTokenStore interface {
+	api.TokenAuthenticator
+	CreateToken(ctx context.Context, token *api.BootstrapToken) error
+}
+
+// adminWebService serves the operator endpoints under /admin. They are kept out
+// of /api/v1 so that read-only mode, which only applies to the API, can always be
+// switched off again. Only operators may use them, see api.OperatorFilter.
+func adminWebService(cfg ServerConfig, handler *handlers.NodeHandler) *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path("/admin").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+	if cfg.BootstrapTokens != nil {
+		ws.Filter(api.BootstrapTokenFilter(cfg.BootstrapTokens))
+	}
+	ws.Filter(api.OperatorFilter)
+
+	mode := cfg.ReadOnly
+	ws.Route(ws.GET("/readonly").To(func(request *restful.Request, response *restful.Response) {
+		api.WriteResponse(response, http.StatusOK, ReadOnlyState{Enabled: mode.Enabled()})
+	}))
+	ws.Route(ws.PUT("/readonly").To(func(request *restful.Request, response *restful.Response) {
+		var state ReadOnlyState
+		if err := request.ReadEntity(&state); err != nil {
+			api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("invalid read-only state: %v", err))
+			return
+		}
+		mode.SetEnabled(state.Enabled)
+		api.WriteResponse(response, http.StatusOK, state)
+	}))
+	if cfg.BootstrapTokens != nil {
+		ws.Route(ws.POST("/bootstrap-tokens").To(createBootstrapToken(cfg.BootstrapTokens)))
+	}
+	handlers.RegisterAdminNodeRoutes(ws, handler)
+	return ws
+}
+
+// createBootstrapToken handles POST /admin/bootstrap-tokens, which lets an operator
+// hand a new node a token to register itself with. A token without groups is
+// given api.BootstrapNodesGroup, without which it couldn't register anything.
+func createBootstrapToken(tokens BootstrapTokenStore) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		token := &api.BootstrapToken{}
+		if err := request.ReadEntity(token); err != nil {
+			api.WriteError(request, response, http.StatusBadRequest, fmt.Errorf("%w: %v", registry.ErrTokenInvalid, err))
+			return
+		}
+		if len(token.Groups) == 0 {
+			token.Groups = []string{api.BootstrapNodesGroup}
+		}
+
+		if err := tokens.CreateToken(request.Request.Context(), token); err != nil {
+			api.WriteError(request, response, registry.StatusCode(err), err)
+			return
+		}
+		api.WriteResponse(response, http.StatusCreated, token)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/api/handlers"
+	"gokube/pkg/metrics"
+)
+
+const (
+	DefaultAddr          = ":8080"
+	DefaultReadTimeout   = 30 * time.Second
+	DefaultWriteTimeout  = 60 * time.Second
+	DefaultShutdownGrace = 10 * time.Second
+)
+
+// ServerConfig holds the settings used to build a Server. Zero values are
+// replaced with defaults by NewServer.
+type ServerConfig struct {
+	// Addr is the TCP address to listen on
+	Addr string
+	// TLSConfig enables HTTPS when set; it must carry the server certificates
+	TLSConfig *tls.Config
+
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	MaxHeaderBytes int
+
+	// ShutdownGrace is how long Stop waits for in-flight requests to finish
+	ShutdownGrace time.Duration
+
+	// RouteTimeouts limits how long individual routes may take, keyed by
+	// "METHOD /path" relative to /api/v1, e.g. "GET /nodes/{name}"
+	RouteTimeouts map[string]time.Duration
+	// StrictDecodingRoutes lists the routes, written like RouteTimeouts keys, that
+	// reject request bodies with unknown fields
+	StrictDecodingRoutes []string
+	// StrictQueryParams rejects Node requests with unknown query parameters
+	StrictQueryParams bool
+	// StrictTypeMeta rejects Node bodies that aren't apiVersion v1, kind Node
+	// instead of defaulting both
+	StrictTypeMeta bool
+
+	// AllowedContentTypes, if set, lists the only content types accepted for POST,
+	// PUT and PATCH bodies; other requests are rejected with 415
+	AllowedContentTypes []string
+
+	// ReadOnly rejects writes to the API while enabled. It can be toggled at runtime
+	// by operators through PUT /admin/readonly; if nil, the server starts with
+	// writes allowed.
+	ReadOnly *api.ReadOnlyMode
+
+	// BootstrapTokens, if set, lets new nodes register themselves with a bootstrap
+	// token, and operators create those tokens through POST /admin/bootstrap-tokens;
+	// without it bearer tokens are ignored
+	BootstrapTokens BootstrapTokenStore
+
+	// ErrorVerbosity says whether clients see the details of server errors; they
+	// do unless it is api.ErrorVerbosityProduction
+	ErrorVerbosity api.ErrorVerbosity
+
+	// RequestMetrics, if set, records every API request
+	RequestMetrics *metrics.RequestMetrics
+
+	// DefaultPageSize and MaxPageSize bound how many Nodes a single list returns
+	DefaultPageSize int
+	MaxPageSize     int
+	// WatchHeartbeat is how often idle watches are sent a Bookmark to keep proxies
+	// from closing them; 0 disables heartbeats
+	WatchHeartbeat time.Duration
+	// StreamIdleTimeout limits how long a single write to a watch or other stream
+	// may take; 0 means no limit. Streams are not bound by WriteTimeout.
+	StreamIdleTimeout time.Duration
+
+	// MaxPageBytes, if set, also ends a list page before its encoded size exceeds it
+	MaxPageBytes int
+}
+
+// withDefaults returns a copy of the config with zero values replaced by defaults
+func (c ServerConfig) withDefaults() ServerConfig {
+	if c.Addr == "" {
+		c.Addr = DefaultAddr
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = DefaultReadTimeout
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = DefaultWriteTimeout
+	}
+	if c.MaxHeaderBytes == 0 {
+		c.MaxHeaderBytes = http.DefaultMaxHeaderBytes
+	}
+	if c.ShutdownGrace == 0 {
+		c.ShutdownGrace = DefaultShutdownGrace
+	}
+	if c.ReadOnly == nil {
+		c.ReadOnly = api.NewReadOnlyMode(false)
+	}
+	if c.DefaultPageSize == 0 {
+		c.DefaultPageSize = handlers.DefaultPageSize
+	}
+	if c.MaxPageSize == 0 {
+		c.MaxPageSize = handlers.MaxPageSize
+	}
+	return c
+}
@@ -1,20 +1,24 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"gokube/pkg/api"
 	"gokube/pkg/api/handlers"
 	"gokube/pkg/registry"
+	"gokube/pkg/version"
 
 	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"gokube/pkg/storage"
 )
 
 // APIServer represents the API server
 type APIServer struct {
-	nodeRegistry *registry.NodeRegistry
+	nodeRegistry registry.NodeInterface
 }
 
 // NewAPIServer creates a new instance of APIServer
@@ -26,23 +30,132 @@ func NewAPIServer(storage storage.Storage) *APIServer {
 
 // Start initializes and starts the API server
 func (s *APIServer) Start(address string) error {
-	container := restful.NewContainer()
-	s.registerRoutes(container)
-
-	return http.ListenAndServe(address, container)
+	return NewServer(ServerConfig{Addr: address}, s.nodeRegistry).Start()
 }
 
 // registerRoutes adds routes to the container
 func (s *APIServer) registerRoutes(container *restful.Container) {
+	addWebService(container, s.nodeRegistry, ServerConfig{}.withDefaults())
+}
+
+// Server is a startable and stoppable HTTP server serving the gokube API
+type Server struct {
+	config     ServerConfig
+	container  *restful.Container
+	httpServer *http.Server
+}
+
+// NewServer builds the restful container, registers all routes and prepares
+// an HTTP server configured from cfg
+func NewServer(cfg ServerConfig, nodeRegistry registry.NodeInterface) *Server {
+	cfg = cfg.withDefaults()
+
+	container := restful.NewContainer()
+	addWebService(container, nodeRegistry, cfg)
+
+	return &Server{
+		config:    cfg,
+		container: container,
+		httpServer: &http.Server{
+			Addr:           cfg.Addr,
+			Handler:        container,
+			TLSConfig:      cfg.TLSConfig,
+			ReadTimeout:    cfg.ReadTimeout,
+			WriteTimeout:   cfg.WriteTimeout,
+			MaxHeaderBytes: cfg.MaxHeaderBytes,
+		},
+	}
+}
+
+// Start listens on the configured address and serves requests until Stop is called
+func (s *Server) Start() error {
+	var err error
+	if s.config.TLSConfig != nil {
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop gracefully shuts the server down, waiting up to ShutdownGrace for
+// in-flight requests to complete
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownGrace)
+	defer cancel()
+
+	return s.httpServer.Shutdown(ctx)
+}
+
+// ServeHTTP serves a single request without going through the listener
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.container.ServeHTTP(w, r)
+}
+
+// addWebService registers the API routes with the container
+func addWebService(container *restful.Container, nodeRegistry registry.NodeInterface, cfg ServerConfig) {
 	ws := new(restful.WebService)
 
 	ws.Path("/api/v1").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
-	ws.Route(ws.GET("/healthz").To(s.healthz))
-	handlers.RegisterNodeRoutes(ws, handlers.NewNodeHandler(s.nodeRegistry))
+	if cfg.RequestMetrics != nil {
+		ws.Filter(cfg.RequestMetrics.Filter)
+	}
+	if cfg.BootstrapTokens != nil {
+		ws.Filter(api.BootstrapTokenFilter(cfg.BootstrapTokens))
+	}
+	ws.Filter(cfg.ReadOnly.Filter)
+	ws.Route(ws.GET("/healthz").To(healthz))
+	ws.Route(ws.GET("/version").To(versionInfo))
+
+	var routeOpts []handlers.RouteOption
+	for route, timeout := range cfg.RouteTimeouts {
+		routeOpts = append(routeOpts, handlers.WithRouteTimeout(route, timeout))
+	}
+	for _, route := range cfg.StrictDecodingRoutes {
+		routeOpts = append(routeOpts, handlers.WithStrictDecoding(route))
+	}
+	if cfg.StrictQueryParams {
+		routeOpts = append(routeOpts, handlers.WithStrictQueryParams())
+	}
+	handlerOpts := []handlers.HandlerOption{
+		handlers.WithPageSize(cfg.DefaultPageSize, cfg.MaxPageSize),
+		handlers.WithStreamTimeouts(cfg.WatchHeartbeat, cfg.StreamIdleTimeout),
+	}
+	if cfg.MaxPageBytes > 0 {
+		handlerOpts = append(handlerOpts, handlers.WithMaxPageBytes(cfg.MaxPageBytes))
+	}
+	if cfg.StrictTypeMeta {
+		handlerOpts = append(handlerOpts, handlers.WithStrictTypeMeta())
+	}
+	handler := handlers.NewNodeHandler(nodeRegistry, handlerOpts...)
+	handlers.RegisterNodeRoutes(ws, handler, routeOpts...)
 
+	container.Filter(api.RequestIDFilter)
+	container.Filter(api.AccessLogFilter)
+	if cfg.ErrorVerbosity != "" {
+		container.Filter(api.ErrorVerbosityFilter(cfg.ErrorVerbosity))
+	}
+	if len(cfg.AllowedContentTypes) > 0 {
+		container.Filter(api.ContentTypeFilter(cfg.AllowedContentTypes...))
+	}
+	container.ServiceErrorHandler(api.WriteServiceError)
 	container.Add(ws)
+	container.Add(adminWebService(cfg, handler))
+
+	// Prometheus metrics are served outside /api/v1, where scrapers expect them
+	container.Handle("/metrics", promhttp.Handler())
+	container.HandleWithFilter("/", http.HandlerFunc(api.NotFound))
 }
 
-func (s *APIServer) healthz(request *restful.Request, response *restful.Response) {
+func healthz(request *restful.Request, response *restful.Response) {
 	api.WriteResponse(response, http.StatusOK, nil)
 }
+
+// versionInfo reports the build of the running server
+func versionInfo(request *restful.Request, response *restful.Response) {
+	api.WriteResponse(response, http.StatusOK, version.Get())
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// LoadTLSConfig returns a TLSConfig serving the certificate and key in certFile and
+// keyFile. With clientCAFile, clients may present a certificate signed by one of the
+// CAs in it, which is then verified and identifies them as an operator or a node, see
+// api.IsOperator and api.NodeIdentity. Clients without a certificate are still
+// served, with no identity.
+func LoadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the serving certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in the client CA file %s", clientCAFile)
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg, nil
+}
+
+// VerifiesClientCertificates reports whether c lets clients authenticate with a
+// verified certificate, which operators and nodes need to be recognized
+func (c ServerConfig) VerifiesClientCertificates() bool {
+	return c.TLSConfig != nil && c.TLSConfig.ClientCAs != nil && c.TLSConfig.ClientAuth >= tls.VerifyClientCertIfGiven
+}
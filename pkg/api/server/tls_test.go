@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mockStorage "gokube/mocks/pkg/storage"
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoadTLSConfig(t *testing.T) {
+	t.Run("should let an operator certificate switch read-only mode off", func(t *testing.T) {
+		dir := t.TempDir()
+		ca, caKey := newTestCA(t, dir)
+		certFile, keyFile := newTestCert(t, dir, "server", ca, caKey, pkix.Name{CommonName: "apiserver"}, x509.ExtKeyUsageServerAuth)
+		clientCert, clientKey := newTestCert(t, dir, "operator", ca, caKey, pkix.Name{CommonName: "operator", Organization: []string{api.OperatorGroup}}, x509.ExtKeyUsageClientAuth)
+
+		tlsConfig, err := LoadTLSConfig(certFile, keyFile, filepath.Join(dir, "ca.crt"))
+		require.NoError(t, err)
+		cfg := ServerConfig{TLSConfig: tlsConfig, ReadOnly: api.NewReadOnlyMode(true)}
+		assert.True(t, cfg.VerifiesClientCertificates())
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		server := httptest.NewUnstartedServer(NewServer(cfg, registry.NewNodeRegistry(mockStorage.NewMockStorage(ctrl))))
+		server.TLS = tlsConfig
+		server.StartTLS()
+		defer server.Close()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		put := func(certificates ...tls.Certificate) *http.Response {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates}}}
+			req, err := http.NewRequest("PUT", server.URL+"/admin/readonly", strings.NewReader(`{"enabled": false}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", restful.MIME_JSON)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, put().StatusCode)
+		assert.True(t, cfg.ReadOnly.Enabled())
+
+		operator, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, put(operator).StatusCode)
+		assert.False(t, cfg.ReadOnly.Enabled())
+	})
+
+	t.Run("should not verify client certificates without a client CA", func(t *testing.T) {
+		dir := t.TempDir()
+		ca, caKey := newTestCA(t, dir)
+		certFile, keyFile := newTestCert(t, dir, "server", ca, caKey, pkix.Name{CommonName: "apiserver"}, x509.ExtKeyUsageServerAuth)
+
+		tlsConfig, err := LoadTLSConfig(certFile, keyFile, "")
+		require.NoError(t, err)
+		assert.False(t, ServerConfig{TLSConfig: tlsConfig}.VerifiesClientCertificates())
+		assert.False(t, ServerConfig{}.VerifiesClientCertificates())
+	})
+
+	t.Run("should reject a client CA file without certificates", func(t *testing.T) {
+		dir := t.TempDir()
+		ca, caKey := newTestCA(t, dir)
+		certFile, keyFile := newTestCert(t, dir, "server", ca, caKey, pkix.Name{CommonName: "apiserver"}, x509.ExtKeyUsageServerAuth)
+		empty := filepath.Join(dir, "empty.crt")
+		require.NoError(t, os.WriteFile(empty, nil, 0o600))
+
+		_, err := LoadTLSConfig(certFile, keyFile, empty)
+		assert.ErrorContains(t, err, "no PEM certificates")
+	})
+}
+
+// newTestCA creates a self-signed CA and writes its certificate to dir/ca.crt
+func newTestCA(t *testing.T, dir string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", der)
+	return ca, key
+}
+
+// newTestCert issues a certificate for subject signed by ca and writes it and its
+// key to dir, returning the paths of both
+func newTestCert(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, subject pkix.Name, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
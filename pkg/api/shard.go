@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// ShardNodes returns the nodes owned by shard shardIndex out of shardCount. Every
+// node is owned by exactly one shard, and changing shardCount only moves the nodes
+// that the added or removed shards take over or give up.
+func ShardNodes(nodes []*Node, shardIndex, shardCount int) []*Node {
+	var owned []*Node
+	for _, node := range nodes {
+		if ShardFor(node.Name, shardCount) == shardIndex {
+			owned = append(owned, node)
+		}
+	}
+	return owned
+}
+
+// ShardFor returns the shard out of shardCount that owns the node named name. It uses
+// rendezvous hashing: each shard scores the name and the highest score wins, so a
+// node only changes owner when the new winner is a shard that was added.
+func ShardFor(name string, shardCount int) int {
+	owner, best := 0, uint64(0)
+	for shard := 0; shard < shardCount; shard++ {
+		if score := shardScore(name, shard); shard == 0 || score > best {
+			owner, best = shard, score
+		}
+	}
+	return owner
+}
+
+// shardScore hashes name together with shard into a well mixed 64-bit score
+func shardScore(name string, shard int) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(shard))
+	_, _ = h.Write(buf[:])
+
+	// splitmix64 finalizer, FNV alone barely changes the high bits for nearby shards
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardNodes(t *testing.T) {
+	nodes := make([]*Node, 1000)
+	for i := range nodes {
+		nodes[i] = &Node{ObjectMeta: ObjectMeta{Name: fmt.Sprintf("node-%d", i)}}
+	}
+
+	t.Run("should assign every node to exactly one shard", func(t *testing.T) {
+		seen := map[string]int{}
+		for shard := 0; shard < 4; shard++ {
+			owned := ShardNodes(nodes, shard, 4)
+			assert.NotEmpty(t, owned)
+			for _, node := range owned {
+				seen[node.Name]++
+			}
+		}
+
+		assert.Len(t, seen, len(nodes))
+		for name, count := range seen {
+			assert.Equal(t, 1, count, name)
+		}
+	})
+
+	t.Run("should move only a minority of nodes when adding a shard", func(t *testing.T) {
+		moved := 0
+		for _, node := range nodes {
+			before, after := ShardFor(node.Name, 4), ShardFor(node.Name, 5)
+			if before != after {
+				moved++
+				// Nodes only ever move to the new shard
+				assert.Equal(t, 4, after)
+			}
+		}
+
+		// Ideally 1/5 of the nodes move to the new shard
+		assert.Less(t, moved, len(nodes)/3)
+		assert.Greater(t, moved, 0)
+	})
+}
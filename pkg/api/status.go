@@ -0,0 +1,83 @@
+package api
+
+import "net/http"
+
+// StatusReason is a machine-readable description of why an operation failed
+type StatusReason string
+
+const (
+	StatusReasonUnknown       StatusReason = ""
+	StatusReasonBadRequest    StatusReason = "BadRequest"
+	StatusReasonUnauthorized  StatusReason = "Unauthorized"
+	StatusReasonForbidden     StatusReason = "Forbidden"
+	StatusReasonNotFound      StatusReason = "NotFound"
+	StatusReasonAlreadyExists StatusReason = "AlreadyExists"
+	StatusReasonInvalid       StatusReason = "Invalid"
+	StatusReasonConflict      StatusReason = "Conflict"
+	// StatusReasonPreconditionFailed means a precondition of the request, such as
+	// If-Unmodified-Since, didn't hold
+	StatusReasonPreconditionFailed StatusReason = "PreconditionFailed"
+	StatusReasonExpired            StatusReason = "Expired"
+	StatusReasonInternalError      StatusReason = "InternalError"
+	StatusReasonTimeout            StatusReason = "Timeout"
+
+	StatusReasonServiceUnavailable StatusReason = "ServiceUnavailable"
+
+	StatusReasonMethodNotAllowed     StatusReason = "MethodNotAllowed"
+	StatusReasonNotAcceptable        StatusReason = "NotAcceptable"
+	StatusReasonUnsupportedMediaType StatusReason = "UnsupportedMediaType"
+	StatusReasonTooLarge             StatusReason = "RequestEntityTooLarge"
+)
+
+// Status is returned for operations that don't return another object
+type Status struct {
+	Status  string       `json:"status,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Reason  StatusReason `json:"reason,omitempty"`
+	Code    int          `json:"code,omitempty"`
+	// RequestID identifies the request that produced this status
+	RequestID string `json:"requestID,omitempty"`
+}
+
+const (
+	StatusSuccess = "Success"
+	StatusFailure = "Failure"
+)
+
+// reasonForCode returns the StatusReason that matches an HTTP status code
+func reasonForCode(code int) StatusReason {
+	switch code {
+	case http.StatusBadRequest:
+		return StatusReasonBadRequest
+	case http.StatusUnauthorized:
+		return StatusReasonUnauthorized
+	case http.StatusForbidden:
+		return StatusReasonForbidden
+	case http.StatusNotFound:
+		return StatusReasonNotFound
+	case http.StatusMethodNotAllowed:
+		return StatusReasonMethodNotAllowed
+	case http.StatusNotAcceptable:
+		return StatusReasonNotAcceptable
+	case http.StatusConflict:
+		return StatusReasonAlreadyExists
+	case http.StatusPreconditionFailed:
+		return StatusReasonPreconditionFailed
+	case http.StatusUnsupportedMediaType:
+		return StatusReasonUnsupportedMediaType
+	case http.StatusRequestEntityTooLarge:
+		return StatusReasonTooLarge
+	case http.StatusUnprocessableEntity:
+		return StatusReasonInvalid
+	case http.StatusGone:
+		return StatusReasonExpired
+	case http.StatusInternalServerError:
+		return StatusReasonInternalError
+	case http.StatusServiceUnavailable:
+		return StatusReasonServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return StatusReasonTimeout
+	default:
+		return StatusReasonUnknown
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// TimeoutFilter returns a route filter that cancels the request context once timeout
+// has passed and replies 504 if the handler hasn't finished by then. The handler's
+// output is buffered until it returns so it can be discarded after a timeout, which
+// also means streamed responses are only sent once complete.
+func TimeoutFilter(timeout time.Duration) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		ctx, cancel := context.WithTimeout(req.Request.Context(), timeout)
+		defer cancel()
+		req.Request = req.Request.WithContext(ctx)
+
+		// Start from the headers earlier filters set, such as X-Request-Id, so the
+		// handler sees them too
+		tw := &timeoutWriter{header: resp.Header().Clone()}
+		buffered := *resp
+		buffered.ResponseWriter = tw
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			chain.ProcessFilter(req, &buffered)
+		}()
+
+		select {
+		case <-done:
+			tw.copyTo(resp)
+		case <-ctx.Done():
+			tw.timeout()
+			WriteError(req, resp, http.StatusGatewayTimeout,
+				fmt.Errorf("%s %s did not complete within %s", req.Request.Method, req.SelectedRoutePath(), timeout))
+		}
+	}
+}
+
+// timeoutWriter buffers a handler's response until it is known whether the handler
+// finished in time
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	code     int
+	buf      bytes.Buffer
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(p)
+}
+
+// timeout discards anything written so far and rejects further writes
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	w.buf.Reset()
+}
+
+// copyTo sends the buffered response to resp
+func (w *timeoutWriter) copyTo(resp *restful.Response) {
+	for key, values := range w.header {
+		resp.Header()[key] = values
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	resp.WriteHeader(w.code)
+	if _, err := resp.Write(w.buf.Bytes()); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
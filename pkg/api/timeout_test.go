@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutFilter(t *testing.T) {
+	newContainer := func(handler restful.RouteFunction) *restful.Container {
+		container := restful.NewContainer()
+		ws := new(restful.WebService)
+		ws.Path("/api/v1").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+		ws.Route(ws.GET("/nodes/{name}").To(handler).Filter(TimeoutFilter(50 * time.Millisecond)))
+		container.Add(ws)
+		return container
+	}
+
+	t.Run("should return gateway timeout when the handler is too slow", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		container := newContainer(func(request *restful.Request, response *restful.Response) {
+			<-request.Request.Context().Done()
+			close(cancelled)
+			WriteResponse(response, http.StatusOK, map[string]string{"name": "late"})
+		})
+
+		req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+		resp := httptest.NewRecorder()
+
+		container.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusGatewayTimeout, resp.Code)
+		var status Status
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		assert.Equal(t, StatusReasonTimeout, status.Reason)
+		assert.Contains(t, status.Message, "GET /api/v1/nodes/{name}")
+
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler context was not cancelled")
+		}
+	})
+
+	t.Run("should pass through a response written in time", func(t *testing.T) {
+		container := newContainer(func(request *restful.Request, response *restful.Response) {
+			response.Header().Set("X-Test", "yes")
+			WriteResponse(response, http.StatusCreated, map[string]string{"name": request.PathParameter("name")})
+		})
+
+		req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+		resp := httptest.NewRecorder()
+
+		container.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Equal(t, "yes", resp.Header().Get("X-Test"))
+		assert.JSONEq(t, `{"name": "test-node"}`, resp.Body.String())
+	})
+	t.Run("should keep the request ID of an error written in time", func(t *testing.T) {
+		container := newContainer(func(request *restful.Request, response *restful.Response) {
+			WriteError(request, response, http.StatusServiceUnavailable, ErrReadOnly)
+		})
+		container.Filter(RequestIDFilter)
+
+		req := httptest.NewRequest("GET", "/api/v1/nodes/test-node", nil)
+		req.Header.Set(HeaderRequestID, "test-request")
+		resp := httptest.NewRecorder()
+
+		container.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		assert.Equal(t, "test-request", resp.Header().Get(HeaderRequestID))
+		var status Status
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		assert.Equal(t, "test-request", status.RequestID)
+	})
+}
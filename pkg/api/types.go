@@ -9,25 +9,45 @@ var (
 	ErrInvalidNodeSpec = errors.New("invalid node spec")
 )
 
+// TypeMeta describes the kind of an object and the API version of its schema
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
 // ObjectMeta is minimal metadata that all persisted resources must have
 type ObjectMeta struct {
 	Name              string    `json:"name" validate:"required"`
+	GenerateName      string    `json:"generateName,omitempty"`
 	Namespace         string    `json:"namespace,omitempty"`
 	UID               string    `json:"uid,omitempty"`
 	ResourceVersion   string    `json:"resourceVersion,omitempty"`
 	CreationTimestamp time.Time `json:"creationTimestamp,omitempty"`
+	LastModified      time.Time `json:"lastModified,omitempty"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // NodeSpec describes the basic attributes of a node
 type NodeSpec struct {
 	Unschedulable bool   `json:"unschedulable,omitempty"`
 	ProviderID    string `json:"providerID,omitempty"`
+	// Pool is the name of the node pool the node belongs to, if any
+	Pool string `json:"pool,omitempty"`
+
+	// CordonReason, CordonedBy and CordonedAt record why, by whom and when the
+	// node was made unschedulable by CordonNode
+	CordonReason string    `json:"cordonReason,omitempty"`
+	CordonedBy   string    `json:"cordonedBy,omitempty"`
+	CordonedAt   time.Time `json:"cordonedAt,omitempty"`
 }
 
 type NodeStatus string
 
 // Define some constants for NodeConditionType and ConditionStatus
 const (
+	NodeUnknown        NodeStatus = "Unknown"
 	NodeNotReady       NodeStatus = "NotReady"
 	NodeReady          NodeStatus = "Ready"
 	NodeMemoryPressure NodeStatus = "MemoryPressure"
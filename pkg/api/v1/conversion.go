@@ -0,0 +1,107 @@
+package v1
+
+import "gokube/pkg/api"
+
+// ConvertToInternal converts a v1 Node to the internal representation
+func ConvertToInternal(in *Node) *api.Node {
+	if in == nil {
+		return nil
+	}
+	return &api.Node{
+		TypeMeta: api.TypeMeta{
+			APIVersion: in.APIVersion,
+			Kind:       in.Kind,
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:              in.Name,
+			GenerateName:      in.GenerateName,
+			Namespace:         in.Namespace,
+			UID:               in.UID,
+			ResourceVersion:   in.ResourceVersion,
+			CreationTimestamp: in.CreationTimestamp,
+			LastModified:      in.LastModified,
+			Labels:            in.Labels,
+			Annotations:       in.Annotations,
+		},
+		Spec: api.NodeSpec{
+			Unschedulable: in.Spec.Unschedulable,
+			ProviderID:    in.Spec.ProviderID,
+			Pool:          in.Spec.Pool,
+			CordonReason:  in.Spec.CordonReason,
+			CordonedBy:    in.Spec.CordonedBy,
+			CordonedAt:    in.Spec.CordonedAt,
+		},
+		Status: api.NodeStatus(in.Status),
+		Info:   convertInfoToInternal(in.Info),
+	}
+}
+
+// ConvertFromInternal converts an internal Node to its v1 representation
+func ConvertFromInternal(in *api.Node) *Node {
+	if in == nil {
+		return nil
+	}
+	return &Node{
+		TypeMeta: TypeMeta{
+			APIVersion: in.APIVersion,
+			Kind:       in.Kind,
+		},
+		ObjectMeta: ObjectMeta{
+			Name:              in.Name,
+			GenerateName:      in.GenerateName,
+			Namespace:         in.Namespace,
+			UID:               in.UID,
+			ResourceVersion:   in.ResourceVersion,
+			CreationTimestamp: in.CreationTimestamp,
+			LastModified:      in.LastModified,
+			Labels:            in.Labels,
+			Annotations:       in.Annotations,
+		},
+		Spec: NodeSpec{
+			Unschedulable: in.Spec.Unschedulable,
+			ProviderID:    in.Spec.ProviderID,
+			Pool:          in.Spec.Pool,
+			CordonReason:  in.Spec.CordonReason,
+			CordonedBy:    in.Spec.CordonedBy,
+			CordonedAt:    in.Spec.CordonedAt,
+		},
+		Status: string(in.Status),
+		Info:   convertInfoFromInternal(in.Info),
+	}
+}
+
+func convertInfoToInternal(in *NodeInfo) *api.NodeInfo {
+	if in == nil {
+		return nil
+	}
+	return &api.NodeInfo{
+		KubeletVersion:          in.KubeletVersion,
+		ContainerRuntimeVersion: in.ContainerRuntimeVersion,
+		OSImage:                 in.OSImage,
+		KernelVersion:           in.KernelVersion,
+	}
+}
+
+func convertInfoFromInternal(in *api.NodeInfo) *NodeInfo {
+	if in == nil {
+		return nil
+	}
+	return &NodeInfo{
+		KubeletVersion:          in.KubeletVersion,
+		ContainerRuntimeVersion: in.ContainerRuntimeVersion,
+		OSImage:                 in.OSImage,
+		KernelVersion:           in.KernelVersion,
+	}
+}
+
+// ConvertListFromInternal converts a list of internal Nodes to v1
+func ConvertListFromInternal(in []*api.Node) []*Node {
+	if in == nil {
+		return nil
+	}
+	out := make([]*Node, 0, len(in))
+	for _, node := range in {
+		out = append(out, ConvertFromInternal(node))
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gokube/pkg/api"
+)
+
+func TestConversion(t *testing.T) {
+	t.Run("should round-trip a v1 node through the internal type unchanged", func(t *testing.T) {
+		in := &Node{
+			TypeMeta: TypeMeta{APIVersion: Version, Kind: KindNode},
+			ObjectMeta: ObjectMeta{
+				Name:              "test-node",
+				GenerateName:      "test-",
+				Namespace:         "default",
+				UID:               "123",
+				ResourceVersion:   "7",
+				CreationTimestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				LastModified:      time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC),
+				Labels:            map[string]string{"zone": "a"},
+				Annotations:       map[string]string{"note": "x"},
+			},
+			Spec: NodeSpec{
+				Unschedulable: true,
+				ProviderID:    "aws:///i-123",
+				Pool:          "gpu",
+				CordonReason:  "kernel upgrade",
+				CordonedBy:    "ops",
+				CordonedAt:    time.Date(2024, 1, 4, 3, 4, 5, 0, time.UTC),
+			},
+			Status: "Ready",
+			Info: &NodeInfo{
+				KubeletVersion:          "v1.30.2",
+				ContainerRuntimeVersion: "containerd://1.7.2",
+				OSImage:                 "Ubuntu 24.04 LTS",
+				KernelVersion:           "6.8.0-31-generic",
+			},
+		}
+
+		assert.Equal(t, in, ConvertFromInternal(ConvertToInternal(in)))
+	})
+
+	t.Run("should keep the v1 wire format identical to the internal encoding", func(t *testing.T) {
+		internal := &api.Node{
+			ObjectMeta: api.ObjectMeta{Name: "test-node", Labels: map[string]string{"zone": "a"}},
+			Status:     api.NodeNotReady,
+		}
+
+		internalJSON, err := json.Marshal(internal)
+		require.NoError(t, err)
+		externalJSON, err := json.Marshal(ConvertFromInternal(internal))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(internalJSON), string(externalJSON))
+	})
+
+	t.Run("should convert nil to nil", func(t *testing.T) {
+		assert.Nil(t, ConvertToInternal(nil))
+		assert.Nil(t, ConvertFromInternal(nil))
+		assert.Nil(t, ConvertListFromInternal(nil))
+	})
+}
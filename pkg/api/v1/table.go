@@ -0,0 +1,25 @@
+package v1
+
+// KindTable is the kind of Table objects
+const KindTable = "Table"
+
+// Table is a tabular rendering of a list, returned instead of the full objects to
+// clients that accept application/json;as=Table
+type Table struct {
+	TypeMeta
+	ColumnDefinitions []TableColumnDefinition `json:"columnDefinitions"`
+	Rows              []TableRow              `json:"rows"`
+}
+
+// TableColumnDefinition describes one column of a Table
+type TableColumnDefinition struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TableRow is one object of a Table, with a cell per column definition
+type TableRow struct {
+	Cells []interface{} `json:"cells"`
+}
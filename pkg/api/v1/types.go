@@ -0,0 +1,64 @@
+// Package v1 holds the v1 wire format of the gokube API. Handlers convert between
+// these types and the internal types in package api, so the internal types can change
+// without breaking v1 clients.
+package v1
+
+import "time"
+
+const (
+	// Version is the apiVersion of objects in the v1 wire format
+	Version = "v1"
+	// KindNode is the kind of Node objects
+	KindNode = "Node"
+)
+
+// TypeMeta is the v1 form of api.TypeMeta
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+// ObjectMeta is the v1 form of api.ObjectMeta
+type ObjectMeta struct {
+	Name              string    `json:"name"`
+	GenerateName      string    `json:"generateName,omitempty"`
+	Namespace         string    `json:"namespace,omitempty"`
+	UID               string    `json:"uid,omitempty"`
+	ResourceVersion   string    `json:"resourceVersion,omitempty"`
+	CreationTimestamp time.Time `json:"creationTimestamp,omitempty"`
+	LastModified      time.Time `json:"lastModified,omitempty"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NodeSpec is the v1 form of api.NodeSpec
+type NodeSpec struct {
+	Unschedulable bool   `json:"unschedulable,omitempty"`
+	ProviderID    string `json:"providerID,omitempty"`
+	// Pool is the name of the node pool the node belongs to, if any
+	Pool string `json:"pool,omitempty"`
+
+	// CordonReason, CordonedBy and CordonedAt record why, by whom and when the
+	// node was made unschedulable by CordonNode
+	CordonReason string    `json:"cordonReason,omitempty"`
+	CordonedBy   string    `json:"cordonedBy,omitempty"`
+	CordonedAt   time.Time `json:"cordonedAt,omitempty"`
+}
+
+// Node is the v1 form of api.Node
+type Node struct {
+	TypeMeta
+	ObjectMeta `json:"metadata,omitempty"`
+	Spec       NodeSpec  `json:"spec,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Info       *NodeInfo `json:"nodeInfo,omitempty"`
+}
+
+// NodeInfo is the v1 form of api.NodeInfo
+type NodeInfo struct {
+	KubeletVersion          string `json:"kubeletVersion,omitempty"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion,omitempty"`
+	OSImage                 string `json:"osImage,omitempty"`
+	KernelVersion           string `json:"kernelVersion,omitempty"`
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	qualifiedNameMaxLength  = 63
+	labelValueMaxLength     = 63
+	dnsSubdomainMaxLength   = 253
+	totalAnnotationSizeMax  = 256 * 1024
+	qualifiedNameCharFmt    = "[A-Za-z0-9]"
+	qualifiedNameExtCharFmt = "[-A-Za-z0-9_.]"
+)
+
+var (
+	qualifiedNameRegexp = regexp.MustCompile("^" + qualifiedNameCharFmt + "(" + qualifiedNameExtCharFmt + "*" + qualifiedNameCharFmt + ")?$")
+	dnsSubdomainRegexp  = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// FieldValidationError is a validation failure of a single field. It wraps
+// ErrInvalidNodeSpec.
+type FieldValidationError struct {
+	// Field is the path of the field, e.g. metadata.labels[zone]
+	Field  string
+	Detail string
+}
+
+func (e *FieldValidationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidNodeSpec, e.Field, e.Detail)
+}
+
+func (e *FieldValidationError) Unwrap() error {
+	return ErrInvalidNodeSpec
+}
+
+// FieldKind returns Field without map keys, e.g. metadata.labels. Unlike Field it
+// takes few distinct values, so it is suitable as a metric label.
+func (e *FieldValidationError) FieldKind() string {
+	kind, _, _ := strings.Cut(e.Field, "[")
+	return kind
+}
+
+// FieldError reports a validation failure for the field at path. Custom validators
+// registered with the registry should use it so their errors read like built-in ones.
+func FieldError(path, format string, args ...interface{}) error {
+	return &FieldValidationError{Field: path, Detail: fmt.Sprintf(format, args...)}
+}
+
+// validateQualifiedName checks a label or annotation key: an optional DNS
+// subdomain prefix followed by "/" and a name of at most 63 characters
+func validateQualifiedName(path, key string) error {
+	name := key
+	if i := strings.Index(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) == 0 {
+			return FieldError(path, "prefix part must be non-empty")
+		}
+		if len(prefix) > dnsSubdomainMaxLength {
+			return FieldError(path, "prefix part must be no more than %d characters", dnsSubdomainMaxLength)
+		}
+		if !dnsSubdomainRegexp.MatchString(prefix) {
+			return FieldError(path, "prefix part must be a lowercase DNS subdomain")
+		}
+	}
+
+	if len(name) == 0 {
+		return FieldError(path, "name part must be non-empty")
+	}
+	if len(name) > qualifiedNameMaxLength {
+		return FieldError(path, "name part must be no more than %d characters", qualifiedNameMaxLength)
+	}
+	if !qualifiedNameRegexp.MatchString(name) {
+		return FieldError(path, "name part must consist of alphanumeric characters, '-', '_' or '.', "+
+			"and must start and end with an alphanumeric character")
+	}
+	return nil
+}
+
+// validateLabels checks label keys and values
+func validateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		path := fmt.Sprintf("metadata.labels[%s]", key)
+		if err := validateQualifiedName(path, key); err != nil {
+			return err
+		}
+		if len(value) > labelValueMaxLength {
+			return FieldError(path, "value must be no more than %d characters", labelValueMaxLength)
+		}
+		if value != "" && !qualifiedNameRegexp.MatchString(value) {
+			return FieldError(path, "value must consist of alphanumeric characters, '-', '_' or '.', "+
+				"and must start and end with an alphanumeric character")
+		}
+	}
+	return nil
+}
+
+// validateAnnotations checks annotation keys. Values may hold arbitrary data,
+// only their combined size is limited.
+func validateAnnotations(annotations map[string]string) error {
+	var totalSize int
+	for key, value := range annotations {
+		if err := validateQualifiedName(fmt.Sprintf("metadata.annotations[%s]", key), key); err != nil {
+			return err
+		}
+		totalSize += len(key) + len(value)
+	}
+
+	if totalSize > totalAnnotationSizeMax {
+		return FieldError("metadata.annotations", "total size must be no more than %d bytes", totalAnnotationSizeMax)
+	}
+	return nil
+}
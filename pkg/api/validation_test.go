@@ -0,0 +1,95 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeLabelAndAnnotationValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		wantErr     string
+	}{
+		{
+			name:   "valid prefixed label key",
+			labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1a"},
+		},
+		{
+			name:   "valid empty label value",
+			labels: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+		},
+		{
+			name:    "bad label value",
+			labels:  map[string]string{"zone": "us east"},
+			wantErr: "metadata.labels[zone]: value must consist of alphanumeric characters",
+		},
+		{
+			name:    "too long label value",
+			labels:  map[string]string{"zone": strings.Repeat("a", 64)},
+			wantErr: "metadata.labels[zone]: value must be no more than 63 characters",
+		},
+		{
+			name:    "too long label key",
+			labels:  map[string]string{strings.Repeat("k", 64): "value"},
+			wantErr: "name part must be no more than 63 characters",
+		},
+		{
+			name:    "uppercase label key prefix",
+			labels:  map[string]string{"Example.com/zone": "a"},
+			wantErr: "metadata.labels[Example.com/zone]: prefix part must be a lowercase DNS subdomain",
+		},
+		{
+			name:    "empty label key prefix",
+			labels:  map[string]string{"/zone": "a"},
+			wantErr: "prefix part must be non-empty",
+		},
+		{
+			name:        "annotation values may hold arbitrary text",
+			annotations: map[string]string{"example.com/description": "rack 12, row b: do not drain!"},
+		},
+		{
+			name:        "bad annotation key",
+			annotations: map[string]string{"not a key": "value"},
+			wantErr:     "metadata.annotations[not a key]: name part must consist of alphanumeric characters",
+		},
+		{
+			name:        "annotations too large",
+			annotations: map[string]string{"example.com/blob": strings.Repeat("x", 256*1024)},
+			wantErr:     "metadata.annotations: total size must be no more than 262144 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &Node{
+				ObjectMeta: ObjectMeta{
+					Name:        "test-node",
+					Labels:      tt.labels,
+					Annotations: tt.annotations,
+				},
+			}
+
+			err := node.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidNodeSpec)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFieldValidationError(t *testing.T) {
+	err := FieldError("metadata.labels[zone]", "value must be no more than %d characters", 63)
+
+	var fieldErr *FieldValidationError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.ErrorIs(t, err, ErrInvalidNodeSpec)
+	assert.Equal(t, "metadata.labels", fieldErr.FieldKind())
+	assert.Equal(t, "invalid node spec: metadata.labels[zone]: value must be no more than 63 characters", err.Error())
+}
@@ -0,0 +1,63 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock allows for injecting fake or real clocks into code that needs to do
+// arbitrary things based on time
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// RealClock really calls time.Now()
+type RealClock struct{}
+
+// Now returns the current time
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Since returns time since the specified timestamp
+func (RealClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// FakeClock is a Clock whose time only changes when told to, for use in tests
+type FakeClock struct {
+	mu   sync.RWMutex
+	time time.Time
+}
+
+// NewFakeClock returns a FakeClock set to t
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{time: t}
+}
+
+// Now returns the fake clock's current time
+func (f *FakeClock) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.time
+}
+
+// Since returns the fake time elapsed since t
+func (f *FakeClock) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// SetTime sets the fake clock to t
+func (f *FakeClock) SetTime(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.time = t
+}
+
+// Step moves the fake clock forward by d
+func (f *FakeClock) Step(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.time = f.time.Add(d)
+}
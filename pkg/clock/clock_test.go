@@ -0,0 +1,23 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	assert.Equal(t, start, c.Now())
+
+	c.Step(90 * time.Second)
+	assert.Equal(t, start.Add(90*time.Second), c.Now())
+	assert.Equal(t, 90*time.Second, c.Since(start))
+
+	later := start.Add(time.Hour)
+	c.SetTime(later)
+	assert.Equal(t, later, c.Now())
+}
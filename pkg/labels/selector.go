@@ -0,0 +1,150 @@
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Set is a map of label keys to values
+type Set map[string]string
+
+// Operator is the comparison a Requirement applies to a label
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a single condition on one label key
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Value    string
+}
+
+// Matches reports whether the labels satisfy the requirement
+func (r Requirement) Matches(ls Set) bool {
+	value, ok := ls[r.Key]
+	switch r.Operator {
+	case Equals:
+		return ok && value == r.Value
+	case NotEquals:
+		return !ok || value != r.Value
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	default:
+		return false
+	}
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	default:
+		return r.Key + string(r.Operator) + r.Value
+	}
+}
+
+// Selector matches label sets against a list of requirements, all of which must hold
+type Selector []Requirement
+
+// Everything returns a selector that matches all label sets
+func Everything() Selector {
+	return nil
+}
+
+// Empty reports whether the selector has no requirements and so matches everything
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+// Matches reports whether the labels satisfy every requirement of the selector
+func (s Selector) Matches(ls Set) bool {
+	for _, r := range s {
+		if !r.Matches(ls) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// SelectorFromSet returns a selector that requires every label in ls to be present
+// with the same value
+func SelectorFromSet(ls Set) Selector {
+	keys := make([]string, 0, len(ls))
+	for key := range ls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s := make(Selector, 0, len(ls))
+	for _, key := range keys {
+		s = append(s, Requirement{Key: key, Operator: Equals, Value: ls[key]})
+	}
+	return s
+}
+
+// Parse parses a comma-separated list of requirements, each one of "key=value",
+// "key==value", "key!=value", "key" (label present) or "!key" (label absent)
+func Parse(selector string) (Selector, error) {
+	var s Selector
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	var r Requirement
+	switch {
+	case strings.Contains(part, "!="):
+		key, value, _ := strings.Cut(part, "!=")
+		r = Requirement{Key: key, Operator: NotEquals, Value: value}
+	case strings.Contains(part, "=="):
+		key, value, _ := strings.Cut(part, "==")
+		r = Requirement{Key: key, Operator: Equals, Value: value}
+	case strings.Contains(part, "="):
+		key, value, _ := strings.Cut(part, "=")
+		r = Requirement{Key: key, Operator: Equals, Value: value}
+	case strings.HasPrefix(part, "!"):
+		r = Requirement{Key: part[1:], Operator: DoesNotExist}
+	default:
+		r = Requirement{Key: part, Operator: Exists}
+	}
+
+	r.Key = strings.TrimSpace(r.Key)
+	r.Value = strings.TrimSpace(r.Value)
+	if r.Key == "" {
+		return Requirement{}, fmt.Errorf("invalid selector requirement %q: missing key", part)
+	}
+	if strings.ContainsAny(r.Key, "=!") || strings.ContainsAny(r.Value, "=!") {
+		return Requirement{}, fmt.Errorf("invalid selector requirement %q", part)
+	}
+	return r, nil
+}
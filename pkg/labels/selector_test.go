@@ -0,0 +1,53 @@
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	ls := Set{"zone": "a", "tier": "gpu"}
+
+	tests := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"zone=a", true},
+		{"zone==a", true},
+		{"zone=b", false},
+		{"zone!=b", true},
+		{"zone!=a", false},
+		{"tier", true},
+		{"missing", false},
+		{"!missing", true},
+		{"!zone", false},
+		{"zone=a, tier=gpu", true},
+		{"zone=a,tier=cpu", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := Parse(tt.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, s.Matches(ls))
+		})
+	}
+
+	t.Run("should reject malformed requirements", func(t *testing.T) {
+		for _, selector := range []string{"=a", "!", "zone=a=b", "zone!=!a"} {
+			_, err := Parse(selector)
+			assert.Error(t, err, selector)
+		}
+	})
+}
+
+func TestSelectorFromSet(t *testing.T) {
+	s := SelectorFromSet(Set{"zone": "a", "tier": "gpu"})
+
+	assert.Equal(t, "tier=gpu,zone=a", s.String())
+	assert.True(t, s.Matches(Set{"zone": "a", "tier": "gpu", "extra": "x"}))
+	assert.False(t, s.Matches(Set{"zone": "a"}))
+}
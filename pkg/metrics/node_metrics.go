@@ -0,0 +1,143 @@
+// Package metrics exposes Prometheus metrics about the objects stored in gokube
+package metrics
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/watch"
+)
+
+// retryInterval is how long Run waits before retrying a failed watch
+const retryInterval = time.Second
+
+// ZoneLabel is the Node label whose value is reported as the zone of the node
+const ZoneLabel = "topology.kubernetes.io/zone"
+
+// nodeKey is the set of label values a Node is counted under
+type nodeKey struct {
+	condition string
+	zone      string
+}
+
+// NodeMetrics maintains the gokube_nodes gauge, the number of Nodes per condition
+// and zone, and the gokube_node_ready_duration_seconds histogram of how long Nodes
+// took from registration to first becoming Ready. Both are kept up to date from Node
+// watch events rather than being recomputed on every scrape.
+type NodeMetrics struct {
+	nodes         *prometheus.GaugeVec
+	readyDuration prometheus.Histogram
+
+	mu      sync.Mutex
+	current map[string]nodeKey
+	// everReady holds the Nodes that have been Ready, whose time to Ready has been
+	// observed or can't be known. It survives a rebuilt watch so that Nodes aren't
+	// observed twice.
+	everReady map[string]bool
+}
+
+// NewNodeMetrics creates the Node gauges and registers them with reg
+func NewNodeMetrics(reg prometheus.Registerer) *NodeMetrics {
+	m := &NodeMetrics{
+		nodes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gokube_nodes",
+			Help: "Number of nodes by condition and zone",
+		}, []string{"condition", "zone"}),
+		readyDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "gokube_node_ready_duration_seconds",
+			Help:    "Time from node registration until the node first became Ready",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		current:   make(map[string]nodeKey),
+		everReady: make(map[string]bool),
+	}
+	reg.MustRegister(m.nodes, m.readyDuration)
+	return m
+}
+
+// Run keeps the gauges in sync with the Nodes in nodeRegistry until ctx is done. If
+// the watch fails or is terminated, the gauges are rebuilt from a fresh watch.
+func (m *NodeMetrics) Run(ctx context.Context, nodeRegistry registry.NodeInterface) {
+	for ctx.Err() == nil {
+		w, err := nodeRegistry.WatchNodesWithInitialEvents(ctx)
+		if err != nil {
+			log.Printf("Error watching nodes for metrics: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		m.reset()
+		for event := range w.ResultChan() {
+			m.handle(event)
+		}
+		w.Stop()
+	}
+}
+
+// handle applies a single watch event to the gauges
+func (m *NodeMetrics) handle(event watch.Event) {
+	node, ok := event.Object.(*api.Node)
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		key := nodeKey{condition: string(node.Status), zone: node.Labels[ZoneLabel]}
+		if old, ok := m.current[node.Name]; ok {
+			if old == key {
+				return
+			}
+			m.nodes.WithLabelValues(old.condition, old.zone).Dec()
+		}
+		m.observeReady(node)
+		m.current[node.Name] = key
+		m.nodes.WithLabelValues(key.condition, key.zone).Inc()
+	case watch.Deleted:
+		if old, ok := m.current[node.Name]; ok {
+			delete(m.current, node.Name)
+			delete(m.everReady, node.Name)
+			m.nodes.WithLabelValues(old.condition, old.zone).Dec()
+		}
+	}
+}
+
+// observeReady records the time to Ready of a Node the first time it is seen
+// Ready, whatever it was before. The update that made the Node Ready set its
+// LastModified, so that is when it became Ready; a Node registered Ready took no
+// time at all. A Node that is already Ready and has been updated since it was
+// registered when it is first seen, as after a restart, became Ready at some
+// unknown time and isn't observed.
+func (m *NodeMetrics) observeReady(node *api.Node) {
+	if node.Status != api.NodeReady || m.everReady[node.Name] {
+		return
+	}
+	m.everReady[node.Name] = true
+
+	_, seen := m.current[node.Name]
+	if !seen && !node.LastModified.Equal(node.CreationTimestamp) {
+		return
+	}
+	m.readyDuration.Observe(node.LastModified.Sub(node.CreationTimestamp).Seconds())
+}
+
+// reset forgets all counted Nodes before the gauges are rebuilt
+func (m *NodeMetrics) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.current = make(map[string]nodeKey)
+	m.nodes.Reset()
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+)
+
+func TestNodeMetrics(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		m := NewNodeMetrics(prometheus.NewRegistry())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		node := &api.Node{
+			ObjectMeta: api.ObjectMeta{Name: "test-node", Labels: map[string]string{ZoneLabel: "a"}},
+			Status:     api.NodeReady,
+		}
+		require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+		go m.Run(ctx, nodeRegistry)
+
+		expectCount := func(condition api.NodeStatus, want float64) {
+			t.Helper()
+			assert.Eventually(t, func() bool {
+				return gaugeValue(t, m, string(condition), "a") == want
+			}, 5*time.Second, 10*time.Millisecond, "%s nodes in zone a", condition)
+		}
+
+		// The existing node is counted from the initial events
+		expectCount(api.NodeReady, 1)
+
+		t.Run("should move a node that becomes NotReady between gauges", func(t *testing.T) {
+			node.Status = api.NodeNotReady
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+
+			expectCount(api.NodeNotReady, 1)
+			expectCount(api.NodeReady, 0)
+		})
+
+		t.Run("should drop deleted nodes to zero", func(t *testing.T) {
+			require.NoError(t, nodeRegistry.DeleteNode(ctx, node.Name))
+
+			expectCount(api.NodeNotReady, 0)
+		})
+	})
+}
+
+func TestNodeMetrics_ReadyDuration(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer), registry.WithClock(fakeClock))
+		m := NewNodeMetrics(prometheus.NewRegistry())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go m.Run(ctx, nodeRegistry)
+
+		// Registered without a status, the node starts out Unknown
+		node := &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}
+		require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+		assert.Eventually(t, func() bool {
+			return gaugeValue(t, m, string(api.NodeUnknown), "") == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// A full update that doesn't carry the creation timestamp
+		fakeClock.Step(90 * time.Second)
+		require.NoError(t, nodeRegistry.UpdateNode(ctx, &api.Node{
+			ObjectMeta: api.ObjectMeta{Name: "test-node"},
+			Status:     api.NodeReady,
+		}))
+
+		histogram := &dto.Metric{}
+		assert.Eventually(t, func() bool {
+			require.NoError(t, m.readyDuration.Write(histogram))
+			return histogram.GetHistogram().GetSampleCount() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 90.0, histogram.GetHistogram().GetSampleSum())
+
+		// Going NotReady and back is not a first transition to Ready
+		fakeClock.Step(time.Minute)
+		node.Status = api.NodeNotReady
+		require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+		node.Status = api.NodeReady
+		require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+		assert.Eventually(t, func() bool {
+			return gaugeValue(t, m, string(api.NodeReady), "") == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, m.readyDuration.Write(histogram))
+		assert.Equal(t, uint64(1), histogram.GetHistogram().GetSampleCount())
+
+		// A node that is NotReady before it first becomes Ready is still observed
+		require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "flapping-node"}}))
+		for _, status := range []api.NodeStatus{api.NodeNotReady, api.NodeReady} {
+			fakeClock.Step(30 * time.Second)
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "flapping-node"}, Status: status}))
+		}
+		assert.Eventually(t, func() bool {
+			require.NoError(t, m.readyDuration.Write(histogram))
+			return histogram.GetHistogram().GetSampleCount() == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 150.0, histogram.GetHistogram().GetSampleSum())
+
+		// A node registered Ready took no time to become Ready
+		require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "ready-node"}, Status: api.NodeReady}))
+		assert.Eventually(t, func() bool {
+			require.NoError(t, m.readyDuration.Write(histogram))
+			return histogram.GetHistogram().GetSampleCount() == 3
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 150.0, histogram.GetHistogram().GetSampleSum())
+	})
+}
+
+func gaugeValue(t *testing.T, m *NodeMetrics, condition, zone string) float64 {
+	metric := &dto.Metric{}
+	require.NoError(t, m.nodes.WithLabelValues(condition, zone).Write(metric))
+	return metric.GetGauge().GetValue()
+}
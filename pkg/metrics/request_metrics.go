@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestMetricsConfig chooses the labels and series RequestMetrics emits. The zero
+// value keeps cardinality low: one series per verb and outcome.
+type RequestMetricsConfig struct {
+	// NodeNameLabel adds a node label with the name of the Node a request is for.
+	// This creates a series per Node, so it is only suited to small clusters.
+	NodeNameLabel bool
+	// DurationHistogram enables the gokube_api_request_duration_seconds histogram
+	// of request latencies per verb
+	DurationHistogram bool
+}
+
+// RequestMetrics counts API requests in gokube_api_requests_total and optionally
+// records their latencies. Filter must be installed on the WebService, not the
+// container, so the path parameters of the selected route are known.
+type RequestMetrics struct {
+	config   RequestMetricsConfig
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewRequestMetrics creates the request metrics selected by config and registers
+// them with reg
+func NewRequestMetrics(reg prometheus.Registerer, config RequestMetricsConfig) *RequestMetrics {
+	labels := []string{"verb", "outcome"}
+	if config.NodeNameLabel {
+		labels = append(labels, "node")
+	}
+
+	m := &RequestMetrics{
+		config: config,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gokube_api_requests_total",
+			Help: "Number of API requests by verb and outcome",
+		}, labels),
+	}
+	reg.MustRegister(m.requests)
+
+	if config.DurationHistogram {
+		m.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gokube_api_request_duration_seconds",
+			Help:    "Latency of API requests by verb",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"verb"})
+		reg.MustRegister(m.duration)
+	}
+	return m
+}
+
+// Filter is a restful.FilterFunction that records every request it passes on
+func (m *RequestMetrics) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	start := time.Now()
+	chain.ProcessFilter(req, resp)
+
+	verb := strings.ToLower(req.Request.Method)
+	values := []string{verb, outcome(resp.StatusCode())}
+	if m.config.NodeNameLabel {
+		values = append(values, req.PathParameter("name"))
+	}
+	m.requests.WithLabelValues(values...).Inc()
+
+	if m.duration != nil {
+		m.duration.WithLabelValues(verb).Observe(time.Since(start).Seconds())
+	}
+}
+
+// outcome groups an HTTP status code into success, client_error or server_error
+func outcome(code int) string {
+	switch {
+	case code == 0 || code < http.StatusBadRequest:
+		return "success"
+	case code < http.StatusInternalServerError:
+		return "client_error"
+	default:
+		return "server_error"
+	}
+}
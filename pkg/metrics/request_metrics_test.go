@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	serve := func(t *testing.T, config RequestMetricsConfig) *prometheus.Registry {
+		reg := prometheus.NewRegistry()
+		m := NewRequestMetrics(reg, config)
+
+		ws := new(restful.WebService)
+		ws.Path("/api/v1").Filter(m.Filter)
+		ws.Route(ws.GET("/nodes/{name}").To(func(req *restful.Request, resp *restful.Response) {
+			if req.PathParameter("name") == "missing" {
+				resp.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		container := restful.NewContainer()
+		container.Add(ws)
+
+		for _, name := range []string{"test-node-1", "test-node-2", "missing"} {
+			container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/nodes/"+name, nil))
+		}
+		return reg
+	}
+
+	t.Run("should label only by verb and outcome by default", func(t *testing.T) {
+		reg := serve(t, RequestMetricsConfig{})
+
+		metrics := requestCounters(t, reg)
+		require.Len(t, metrics, 2)
+		for _, metric := range metrics {
+			var names []string
+			for _, label := range metric.GetLabel() {
+				names = append(names, label.GetName())
+			}
+			assert.Equal(t, []string{"outcome", "verb"}, names)
+		}
+		assert.Equal(t, 2.0, metrics[1].GetCounter().GetValue())
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		assert.Len(t, families, 1, "no histogram unless enabled")
+	})
+
+	t.Run("should add node names and latencies when enabled", func(t *testing.T) {
+		reg := serve(t, RequestMetricsConfig{NodeNameLabel: true, DurationHistogram: true})
+
+		metrics := requestCounters(t, reg)
+		require.Len(t, metrics, 3)
+		var nodes []string
+		for _, metric := range metrics {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "node" {
+					nodes = append(nodes, label.GetValue())
+				}
+			}
+		}
+		assert.ElementsMatch(t, []string{"test-node-1", "test-node-2", "missing"}, nodes)
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		assert.Len(t, families, 2)
+	})
+}
+
+// requestCounters returns the gokube_api_requests_total series gathered from reg
+func requestCounters(t *testing.T, reg *prometheus.Registry) []*dto.Metric {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "gokube_api_requests_total" {
+			return family.GetMetric()
+		}
+	}
+	t.Fatal("gokube_api_requests_total not gathered")
+	return nil
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// RetentionMetrics counts the event log entries purged by retention in
+// gokube_event_log_purged_total, by whether they were plain events or tombstones
+type RetentionMetrics struct {
+	purged *prometheus.CounterVec
+}
+
+// NewRetentionMetrics creates the retention metrics and registers them with reg
+func NewRetentionMetrics(reg prometheus.Registerer) *RetentionMetrics {
+	m := &RetentionMetrics{
+		purged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gokube_event_log_purged_total",
+			Help: "Number of event log entries purged by retention, by kind",
+		}, []string{"kind"}),
+	}
+	reg.MustRegister(m.purged)
+	return m
+}
+
+// Observe counts purged entries of kind; pass it to registry.RunEventLogPurger
+func (m *RetentionMetrics) Observe(kind string, purged int) {
+	m.purged.WithLabelValues(kind).Add(float64(purged))
+}
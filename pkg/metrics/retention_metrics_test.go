@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"gokube/pkg/registry"
+)
+
+func TestRetentionMetrics(t *testing.T) {
+	m := NewRetentionMetrics(prometheus.NewRegistry())
+
+	m.Observe(registry.PurgedEvents, 3)
+	m.Observe(registry.PurgedEvents, 2)
+	m.Observe(registry.PurgedTombstones, 1)
+
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.purged.WithLabelValues(registry.PurgedEvents)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purged.WithLabelValues(registry.PurgedTombstones)))
+}
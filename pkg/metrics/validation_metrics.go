@@ -0,0 +1,28 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// ValidationMetrics counts Node validation failures in
+// gokube_node_validation_failures_total by the kind of field that failed, so
+// operators can see which rules reject the most Nodes
+type ValidationMetrics struct {
+	failures *prometheus.CounterVec
+}
+
+// NewValidationMetrics creates the validation metrics and registers them with reg
+func NewValidationMetrics(reg prometheus.Registerer) *ValidationMetrics {
+	m := &ValidationMetrics{
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gokube_node_validation_failures_total",
+			Help: "Number of Node validation failures by field",
+		}, []string{"field"}),
+	}
+	reg.MustRegister(m.failures)
+	return m
+}
+
+// Observe counts a validation failure of field; pass it to
+// registry.WithValidationObserver
+func (m *ValidationMetrics) Observe(field string) {
+	m.failures.WithLabelValues(field).Inc()
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+)
+
+func TestValidationMetrics(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		reg := prometheus.NewRegistry()
+		m := NewValidationMetrics(reg)
+		nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer), registry.WithValidationObserver(m.Observe))
+		ctx := context.Background()
+
+		for _, value := range []string{"not valid!", "-bad"} {
+			node := &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node", Labels: map[string]string{"zone": value}}}
+			require.ErrorIs(t, nodeRegistry.CreateNode(ctx, node), registry.ErrNodeInvalid)
+		}
+		require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(m.failures.WithLabelValues("metadata.labels")))
+		assert.Equal(t, 1, testutil.CollectAndCount(m.failures))
+	})
+}
@@ -0,0 +1,189 @@
+// Package notify posts Node changes to an external webhook
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/watch"
+)
+
+const (
+	// DefaultAnnotation is the annotation a Node needs to be notified about
+	DefaultAnnotation = "notify"
+	// DefaultAnnotationValue is the value DefaultAnnotation must have
+	DefaultAnnotationValue = "true"
+
+	defaultRetries       = 3
+	defaultRetryInterval = time.Second
+	defaultTimeout       = 10 * time.Second
+)
+
+// Notification is the summary of a Node change posted to the webhook
+type Notification struct {
+	Type   watch.EventType   `json:"type"`
+	Name   string            `json:"name"`
+	Status api.NodeStatus    `json:"status,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Config configures a Notifier. Zero values are replaced with defaults.
+type Config struct {
+	// URL is the webhook endpoint notifications are POSTed to
+	URL string
+	// Annotation and AnnotationValue select the Nodes that are notified about
+	Annotation      string
+	AnnotationValue string
+
+	// Retries is how many times a failed delivery is retried, waiting RetryInterval
+	// in between, before the notification is dead-lettered
+	Retries       int
+	RetryInterval time.Duration
+
+	Client *http.Client
+	// DeadLetter receives notifications that could not be delivered; by default
+	// they are logged
+	DeadLetter func(n Notification, err error)
+}
+
+// Notifier posts a Notification for every change to a Node carrying the configured
+// annotation. Notifications are delivered one at a time in event order.
+type Notifier struct {
+	config Config
+	// matching holds the names of the Nodes carrying the annotation, so that their
+	// deletion, whose event only has the name, can be notified too
+	matching map[string]bool
+}
+
+// NewNotifier creates a Notifier from cfg
+func NewNotifier(cfg Config) *Notifier {
+	if cfg.Annotation == "" {
+		cfg.Annotation = DefaultAnnotation
+		cfg.AnnotationValue = DefaultAnnotationValue
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = defaultRetryInterval
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	if cfg.DeadLetter == nil {
+		cfg.DeadLetter = func(n Notification, err error) {
+			log.Printf("Dropping %s notification for node %s: %v", n.Type, n.Name, err)
+		}
+	}
+	return &Notifier{config: cfg, matching: make(map[string]bool)}
+}
+
+// Run notifies about changes to the Nodes in nodeRegistry until ctx is done. Nodes
+// that exist when Run starts are not notified about until they change. If the watch
+// fails or is terminated, e.g. because a slow webhook held it up, Run starts a new one.
+func (n *Notifier) Run(ctx context.Context, nodeRegistry registry.NodeInterface) {
+	for ctx.Err() == nil {
+		w, err := nodeRegistry.WatchNodesWithInitialEvents(ctx)
+		if err != nil {
+			log.Printf("Error watching nodes for notifications: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(n.config.RetryInterval):
+			}
+			continue
+		}
+
+		n.matching = make(map[string]bool)
+		synced := false
+		for event := range w.ResultChan() {
+			if event.Type == watch.Bookmark {
+				synced = true
+				continue
+			}
+			if notification, ok := n.match(event); ok && synced {
+				n.deliver(ctx, notification)
+			}
+		}
+		w.Stop()
+	}
+}
+
+// match tracks which Nodes carry the annotation and returns the notification for
+// event if its Node does
+func (n *Notifier) match(event watch.Event) (Notification, bool) {
+	node, ok := event.Object.(*api.Node)
+	if !ok {
+		return Notification{}, false
+	}
+
+	notification := Notification{Type: event.Type, Name: node.Name, Status: node.Status, Labels: node.Labels}
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		wasMatching := n.matching[node.Name]
+		matching := node.Annotations[n.config.Annotation] == n.config.AnnotationValue
+		if matching {
+			n.matching[node.Name] = true
+		} else {
+			delete(n.matching, node.Name)
+		}
+		// Removing the annotation is the last change notified about
+		return notification, matching || wasMatching
+	case watch.Deleted:
+		matching := n.matching[node.Name]
+		delete(n.matching, node.Name)
+		return notification, matching
+	default:
+		return Notification{}, false
+	}
+}
+
+// deliver posts notification, retrying failures, and dead-letters it if every
+// attempt fails
+func (n *Notifier) deliver(ctx context.Context, notification Notification) {
+	var err error
+	for attempt := 0; attempt <= n.config.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				n.config.DeadLetter(notification, ctx.Err())
+				return
+			case <-time.After(n.config.RetryInterval):
+			}
+		}
+		if err = n.post(ctx, notification); err == nil {
+			return
+		}
+	}
+	n.config.DeadLetter(notification, err)
+}
+
+func (n *Notifier) post(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook replied %s", resp.Status)
+	}
+	return nil
+}
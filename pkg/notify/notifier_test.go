@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/registry"
+	"gokube/pkg/storage"
+	"gokube/pkg/watch"
+)
+
+func TestNotifier(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := registry.NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		t.Run("should notify about matching nodes only", func(t *testing.T) {
+			received := make(chan Notification, 10)
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var n Notification
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+				received <- n
+			}))
+			defer receiver.Close()
+
+			watched := &api.Node{ObjectMeta: api.ObjectMeta{Name: "watched-node", Annotations: map[string]string{"notify": "true"}}}
+			ignored := &api.Node{ObjectMeta: api.ObjectMeta{Name: "ignored-node"}}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, watched))
+			require.NoError(t, nodeRegistry.CreateNode(ctx, ignored))
+
+			runCtx, stop := context.WithCancel(ctx)
+			defer stop()
+			go NewNotifier(Config{URL: receiver.URL}).Run(runCtx, nodeRegistry)
+
+			// Give the notifier time to sync before changing the nodes
+			time.Sleep(200 * time.Millisecond)
+			ignored.Status = api.NodeReady
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, ignored))
+			watched.Status = api.NodeReady
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, watched))
+			require.NoError(t, nodeRegistry.DeleteNode(ctx, "watched-node"))
+
+			for _, want := range []Notification{
+				{Type: watch.Modified, Name: "watched-node", Status: api.NodeReady},
+				{Type: watch.Deleted, Name: "watched-node", Status: api.NodeReady},
+			} {
+				select {
+				case n := <-received:
+					assert.Equal(t, want, n)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("no %s notification received", want.Type)
+				}
+			}
+			select {
+			case n := <-received:
+				t.Fatalf("unexpected notification %+v", n)
+			case <-time.After(100 * time.Millisecond):
+			}
+		})
+
+		t.Run("should dead-letter a notification that keeps failing", func(t *testing.T) {
+			attempts := 0
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer receiver.Close()
+
+			deadLetters := make(chan error, 1)
+			notifier := NewNotifier(Config{
+				URL:           receiver.URL,
+				Retries:       2,
+				RetryInterval: time.Millisecond,
+				DeadLetter:    func(n Notification, err error) { deadLetters <- err },
+			})
+
+			notifier.deliver(ctx, Notification{Type: watch.Modified, Name: "watched-node"})
+
+			select {
+			case err := <-deadLetters:
+				assert.Contains(t, err.Error(), "503")
+			default:
+				t.Fatal("notification was not dead-lettered")
+			}
+			assert.Equal(t, 3, attempts)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gokube/pkg/api"
+)
+
+// ErrAdmissionDenied is returned when an admission plugin rejects a Node
+var ErrAdmissionDenied = errors.New("admission denied")
+
+// Operation is the kind of write an admission plugin is asked about
+type Operation string
+
+const (
+	OperationCreate Operation = "CREATE"
+	OperationUpdate Operation = "UPDATE"
+)
+
+// Admission inspects a Node before it is created or updated and may reject it by
+// returning an error wrapping ErrAdmissionDenied
+type Admission interface {
+	Admit(ctx context.Context, op Operation, node *api.Node) error
+}
+
+// WithAdmission adds validating admission plugins, run in order on every create and
+// update once the Node has passed validation
+func WithAdmission(plugins ...Admission) Option {
+	return func(r *NodeRegistry) {
+		r.admission = append(r.admission, plugins...)
+	}
+}
+
+// WithMutatingAdmission adds admission plugins that may change the Node. They run
+// in order before validation, so whatever they set is validated like the rest of
+// the Node.
+func WithMutatingAdmission(plugins ...Admission) Option {
+	return func(r *NodeRegistry) {
+		r.mutatingAdmission = append(r.mutatingAdmission, plugins...)
+	}
+}
+
+// admitAndValidate runs the mutating admission plugins, then validation, then the
+// validating plugins, stopping at the first rejection
+func (r *NodeRegistry) admitAndValidate(ctx context.Context, op Operation, node *api.Node) error {
+	if err := admit(ctx, r.mutatingAdmission, op, node); err != nil {
+		return err
+	}
+	if err := r.validateWrite(node); err != nil {
+		return fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+	return admit(ctx, r.admission, op, node)
+}
+
+// admit runs plugins in order, stopping at the first rejection
+func admit(ctx context.Context, plugins []Admission, op Operation, node *api.Node) error {
+	for _, plugin := range plugins {
+		if err := plugin.Admit(ctx, op, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequiredLabelsAdmission rejects Nodes that lack any of a set of labels or have
+// them set to an empty value
+type RequiredLabelsAdmission struct {
+	keys []string
+}
+
+// NewRequiredLabelsAdmission creates a RequiredLabelsAdmission requiring keys
+func NewRequiredLabelsAdmission(keys ...string) *RequiredLabelsAdmission {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return &RequiredLabelsAdmission{keys: sorted}
+}
+
+// Admit implements Admission
+func (a *RequiredLabelsAdmission) Admit(_ context.Context, _ Operation, node *api.Node) error {
+	var missing []string
+	for _, key := range a.keys {
+		if node.Labels[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: node %s is missing required labels: %s", ErrAdmissionDenied, node.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
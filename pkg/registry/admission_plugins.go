@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RequiredLabelsPlugin is the name RequiredLabelsAdmission is registered under
+const RequiredLabelsPlugin = "RequiredLabels"
+
+// admissionPlugin is a named Admission in AdmissionPlugins
+type admissionPlugin struct {
+	admission Admission
+	mutating  bool
+}
+
+// AdmissionPlugins is a set of named admission plugins an operator can choose from.
+// Mutating plugins may change the Node, validating plugins only check it; so that
+// validators see the final Node, every mutator must run before every validator.
+type AdmissionPlugins struct {
+	plugins map[string]admissionPlugin
+}
+
+// NewAdmissionPlugins creates an empty plugin set
+func NewAdmissionPlugins() *AdmissionPlugins {
+	return &AdmissionPlugins{plugins: make(map[string]admissionPlugin)}
+}
+
+// Register adds a plugin under name. It panics if name is already taken, since
+// that is a programming error.
+func (p *AdmissionPlugins) Register(name string, admission Admission, mutating bool) {
+	if _, ok := p.plugins[name]; ok {
+		panic(fmt.Sprintf("admission plugin %q registered twice", name))
+	}
+	p.plugins[name] = admissionPlugin{admission: admission, mutating: mutating}
+}
+
+// Names returns the registered plugin names in alphabetical order
+func (p *AdmissionPlugins) Names() []string {
+	names := make([]string, 0, len(p.plugins))
+	for name := range p.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enabled returns an Option that installs the plugins named in enabled in that
+// order, the mutating ones with WithMutatingAdmission and the validating ones with
+// WithAdmission. Plugins that aren't listed don't run. It fails for unknown or
+// repeated names and for a mutating plugin listed after a validating one.
+func (p *AdmissionPlugins) Enabled(enabled []string) (Option, error) {
+	var mutating, validating []Admission
+	seen := make(map[string]bool, len(enabled))
+	validator := ""
+	for _, name := range enabled {
+		plugin, ok := p.plugins[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown admission plugin %q, known plugins are %v", name, p.Names())
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("admission plugin %q is enabled twice", name)
+		}
+		seen[name] = true
+
+		if plugin.mutating && validator != "" {
+			return nil, fmt.Errorf("mutating admission plugin %q must run before validating plugin %q", name, validator)
+		}
+		if plugin.mutating {
+			mutating = append(mutating, plugin.admission)
+		} else {
+			validating = append(validating, plugin.admission)
+			if validator == "" {
+				validator = name
+			}
+		}
+	}
+	return func(r *NodeRegistry) {
+		WithMutatingAdmission(mutating...)(r)
+		WithAdmission(validating...)(r)
+	}, nil
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/storage"
+)
+
+func TestRequiredLabelsAdmission(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		etcdStorage := storage.NewEtcdStorage(etcdServer)
+		nodeRegistry := NewNodeRegistry(etcdStorage, WithAdmission(NewRequiredLabelsAdmission("team", "env")))
+		ctx := context.Background()
+
+		t.Run("should reject a node missing a required label", func(t *testing.T) {
+			node := createTestNode("unlabelled-node", "201")
+			node.Labels = map[string]string{"team": "infra", "env": ""}
+
+			err := nodeRegistry.CreateNode(ctx, node)
+			assert.ErrorIs(t, err, ErrAdmissionDenied)
+			assert.Contains(t, err.Error(), "env")
+			assert.NotContains(t, err.Error(), "team")
+
+			_, err = nodeRegistry.GetNode(ctx, "unlabelled-node")
+			assert.ErrorIs(t, err, ErrNodeNotFound)
+		})
+
+		t.Run("should accept a node with all required labels", func(t *testing.T) {
+			node := createTestNode("labelled-node", "202")
+			node.Labels = map[string]string{"team": "infra", "env": "prod"}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			// Dropping a required label on update is rejected too
+			delete(node.Labels, "team")
+			err := nodeRegistry.UpdateNode(ctx, node)
+			assert.ErrorIs(t, err, ErrAdmissionDenied)
+		})
+	})
+}
+
+// labelAdmission is a mutating test plugin that sets a label
+type labelAdmission struct {
+	key, value string
+}
+
+func (a *labelAdmission) Admit(_ context.Context, _ Operation, node *api.Node) error {
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels[a.key] = a.value
+	return nil
+}
+
+func TestAdmissionPlugins(t *testing.T) {
+	plugins := NewAdmissionPlugins()
+	plugins.Register(RequiredLabelsPlugin, NewRequiredLabelsAdmission("team"), false)
+	plugins.Register("DefaultTeam", &labelAdmission{key: "team", value: "infra"}, true)
+
+	t.Run("should run the enabled plugins in order", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			admission, err := plugins.Enabled([]string{"DefaultTeam", RequiredLabelsPlugin})
+			require.NoError(t, err)
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), admission)
+
+			node := createTestNode("defaulted-node", "203")
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), node))
+			assert.Equal(t, "infra", node.Labels["team"])
+		})
+	})
+
+	t.Run("should let nodes through plugins that are not enabled", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			admission, err := plugins.Enabled(nil)
+			require.NoError(t, err)
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), admission)
+
+			node := createTestNode("unlabelled-node", "204")
+			require.NoError(t, nodeRegistry.CreateNode(context.Background(), node))
+		})
+	})
+
+	t.Run("should validate what mutating plugins set", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			plugins := NewAdmissionPlugins()
+			plugins.Register("BadLabel", &labelAdmission{key: "not a label", value: "x"}, true)
+			admission, err := plugins.Enabled([]string{"BadLabel"})
+			require.NoError(t, err)
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), admission)
+
+			err = nodeRegistry.CreateNode(context.Background(), createTestNode("mutated-node", "205"))
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+	})
+
+	t.Run("should reject a mutator after a validator", func(t *testing.T) {
+		_, err := plugins.Enabled([]string{RequiredLabelsPlugin, "DefaultTeam"})
+		assert.ErrorContains(t, err, `"DefaultTeam" must run before validating plugin "RequiredLabels"`)
+	})
+
+	t.Run("should reject unknown and repeated plugins", func(t *testing.T) {
+		_, err := plugins.Enabled([]string{"Quota"})
+		assert.ErrorContains(t, err, `unknown admission plugin "Quota"`)
+
+		_, err = plugins.Enabled([]string{RequiredLabelsPlugin, RequiredLabelsPlugin})
+		assert.ErrorContains(t, err, "enabled twice")
+	})
+}
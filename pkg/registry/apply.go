@@ -0,0 +1,289 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gokube/pkg/api"
+	v1 "gokube/pkg/api/v1"
+)
+
+// LastAppliedConfigAnnotation holds the configuration a client last applied to a
+// Node, as kubectl apply records it. It is stored like any other annotation.
+const LastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// FieldManagersAnnotation records the configuration each field manager last applied
+// with ApplyNode, as a JSON object keyed by manager. A manager owns the fields of
+// its configuration.
+const FieldManagersAnnotation = "gokube.io/field-managers"
+
+// ErrPatchInvalid is returned when applying a configuration would leave a Node
+// that fails validation. It is told apart from ErrNodeInvalid because the request
+// itself was well-formed, only its result was not.
+var ErrPatchInvalid = errors.New("patched node is invalid")
+
+// ApplyPatch returns the JSON merge patch that applying the configuration applied
+// to the Node name would make. It is a three-way diff: fields that the last applied
+// configuration set but applied leaves out are removed, fields applied sets are
+// changed where the stored Node differs, and fields set by others are kept. The
+// patch also records applied as the new last applied configuration.
+func (r *NodeRegistry) ApplyPatch(ctx context.Context, name string, applied []byte) ([]byte, error) {
+	node, err := r.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var modified map[string]interface{}
+	if err := json.Unmarshal(applied, &modified); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+
+	original := map[string]interface{}{}
+	if lastApplied, ok := node.Annotations[LastAppliedConfigAnnotation]; ok {
+		if err := json.Unmarshal([]byte(lastApplied), &original); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s annotation: %v", ErrNodeInvalid, LastAppliedConfigAnnotation, err)
+		}
+	}
+
+	current, err := toJSONMap(node)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := threeWayMergePatch(original, modified, current)
+	setNested(patch, string(applied), "metadata", "annotations", LastAppliedConfigAnnotation)
+	return json.Marshal(patch)
+}
+
+// ApplyNode applies the configuration applied to the Node name on behalf of
+// fieldManager and returns the resulting Node. Like ApplyPatch it is a three-way
+// merge, but against the configuration fieldManager applied last, so managers that
+// apply different fields of a Node never remove each other's fields. A Node that
+// doesn't exist yet is created from applied.
+func (r *NodeRegistry) ApplyNode(ctx context.Context, name, fieldManager string, applied []byte) (*api.Node, error) {
+	if fieldManager == "" {
+		return nil, fmt.Errorf("%w: a field manager is required to apply", ErrNodeInvalid)
+	}
+
+	var modified map[string]interface{}
+	if err := json.Unmarshal(applied, &modified); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+	if appliedName, _ := nestedString(modified, "metadata", "name"); appliedName != name {
+		return nil, fmt.Errorf("%w: applied configuration is for node %q, not %q", ErrNodeInvalid, appliedName, name)
+	}
+
+	var result *api.Node
+	err := r.UpdateNodeWithRetry(ctx, name, func(node *api.Node) error {
+		managers, err := fieldManagers(node)
+		if err != nil {
+			return err
+		}
+		original := map[string]interface{}{}
+		if last, ok := managers[fieldManager]; ok {
+			if err := json.Unmarshal(last, &original); err != nil {
+				return fmt.Errorf("%w: invalid %s annotation: %v", ErrNodeInvalid, FieldManagersAnnotation, err)
+			}
+		}
+
+		current, err := toJSONMap(node)
+		if err != nil {
+			return err
+		}
+		merged := &api.Node{}
+		if err := fromJSONMap(mergePatch(current, threeWayMergePatch(original, modified, current)), merged); err != nil {
+			return err
+		}
+		// Check the merged result the same way create does, so a patch can't
+		// produce a Node that couldn't have been created
+		if err := r.validateWrite(merged); err != nil {
+			return fmt.Errorf("%w: %v", ErrPatchInvalid, err)
+		}
+
+		managers[fieldManager] = applied
+		if err := setFieldManagers(merged, managers); err != nil {
+			return err
+		}
+		*node = *merged
+		result = node
+		return nil
+	})
+	if !errors.Is(err, ErrNodeNotFound) {
+		return result, err
+	}
+
+	node := &api.Node{}
+	if err := decodeNode(applied, node); err != nil {
+		return nil, err
+	}
+	if err := setFieldManagers(node, map[string]json.RawMessage{fieldManager: applied}); err != nil {
+		return nil, err
+	}
+	if err := r.CreateNode(ctx, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// MergeNode updates the Node name with the fields set in the JSON object body and
+// keeps the ones body leaves out, like a JSON merge patch (RFC 7386): objects are
+// merged, anything else is replaced and null removes a field. UpdateNode instead
+// replaces the whole Node, clearing every field that isn't set.
+func (r *NodeRegistry) MergeNode(ctx context.Context, name string, body []byte) (*api.Node, error) {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+
+	var result *api.Node
+	err := r.UpdateNodeWithRetry(ctx, name, func(node *api.Node) error {
+		current, err := toJSONMap(node)
+		if err != nil {
+			return err
+		}
+		merged := &api.Node{}
+		if err := fromJSONMap(mergePatch(current, patch), merged); err != nil {
+			return err
+		}
+		*node = *merged
+		result = node
+		return nil
+	})
+	return result, err
+}
+
+// fieldManagers returns the configurations recorded in FieldManagersAnnotation
+func fieldManagers(node *api.Node) (map[string]json.RawMessage, error) {
+	managers := map[string]json.RawMessage{}
+	if value, ok := node.Annotations[FieldManagersAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &managers); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s annotation: %v", ErrNodeInvalid, FieldManagersAnnotation, err)
+		}
+	}
+	return managers, nil
+}
+
+// setFieldManagers records managers in the FieldManagersAnnotation of node
+func setFieldManagers(node *api.Node, managers map[string]json.RawMessage) error {
+	data, err := json.Marshal(managers)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[FieldManagersAnnotation] = string(data)
+	return nil
+}
+
+// mergePatch applies the JSON merge patch (RFC 7386) patch to target in place
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		patchMap, patchIsMap := value.(map[string]interface{})
+		targetMap, targetIsMap := target[key].(map[string]interface{})
+		if patchIsMap && targetIsMap {
+			target[key] = mergePatch(targetMap, patchMap)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
+
+// nestedString returns the string at path in m
+func nestedString(m map[string]interface{}, path ...string) (string, bool) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		m = next
+	}
+	s, ok := m[path[len(path)-1]].(string)
+	return s, ok
+}
+
+// threeWayMergePatch computes a JSON merge patch turning current into modified,
+// deleting only the fields that original had and modified dropped
+func threeWayMergePatch(original, modified, current map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key := range original {
+		if _, ok := modified[key]; ok {
+			continue
+		}
+		if _, ok := current[key]; ok {
+			patch[key] = nil
+		}
+	}
+
+	for key, modifiedValue := range modified {
+		currentValue, inCurrent := current[key]
+		modifiedMap, modifiedIsMap := modifiedValue.(map[string]interface{})
+		currentMap, currentIsMap := currentValue.(map[string]interface{})
+		if modifiedIsMap && currentIsMap {
+			originalMap, _ := original[key].(map[string]interface{})
+			if sub := threeWayMergePatch(originalMap, modifiedMap, currentMap); len(sub) > 0 {
+				patch[key] = sub
+			}
+			continue
+		}
+		if !inCurrent || !reflect.DeepEqual(modifiedValue, currentValue) {
+			patch[key] = modifiedValue
+		}
+	}
+	return patch
+}
+
+// setNested sets value at path in m, creating intermediate maps as needed
+func setNested(m map[string]interface{}, value interface{}, path ...string) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+// toJSONMap returns node in the v1 wire format as a JSON object, the form clients
+// write the configurations merged into it in
+func toJSONMap(node *api.Node) (map[string]interface{}, error) {
+	data, err := json.Marshal(v1.ConvertFromInternal(node))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return m, nil
+}
+
+// fromJSONMap decodes the v1 Node m into node
+func fromJSONMap(m map[string]interface{}, node *api.Node) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return decodeNode(data, node)
+}
+
+// decodeNode decodes the v1 Node data into node, converting it the way the
+// handlers convert the Nodes they decode
+func decodeNode(data []byte, node *api.Node) error {
+	external := &v1.Node{}
+	if err := json.Unmarshal(data, external); err != nil {
+		return fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+	*node = *v1.ConvertToInternal(external)
+	return nil
+}
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/storage"
+)
+
+func TestNodeRegistry_ApplyPatch(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		lastApplied := `{"metadata":{"name":"test-node","labels":{"team":"infra","env":"prod"}}}`
+		node := &api.Node{ObjectMeta: api.ObjectMeta{
+			Name:        "test-node",
+			Labels:      map[string]string{"team": "infra", "env": "prod"},
+			Annotations: map[string]string{LastAppliedConfigAnnotation: lastApplied},
+		}}
+		require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+		t.Run("should preserve the annotation across updates", func(t *testing.T) {
+			// Another client adds a label of its own
+			node.Labels["zone"] = "a"
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+
+			stored, err := nodeRegistry.GetNode(ctx, "test-node")
+			require.NoError(t, err)
+			assert.Equal(t, lastApplied, stored.Annotations[LastAppliedConfigAnnotation])
+		})
+
+		t.Run("should diff against the last applied configuration", func(t *testing.T) {
+			applied := `{"metadata":{"name":"test-node","labels":{"team":"platform"}},"spec":{"unschedulable":true}}`
+
+			patch, err := nodeRegistry.ApplyPatch(ctx, "test-node", []byte(applied))
+			require.NoError(t, err)
+
+			// env was applied before and is dropped, team changes, zone isn't ours to remove
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(patch, &got))
+			assert.Equal(t, map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels":      map[string]interface{}{"env": nil, "team": "platform"},
+					"annotations": map[string]interface{}{LastAppliedConfigAnnotation: applied},
+				},
+				"spec": map[string]interface{}{"unschedulable": true},
+			}, got)
+		})
+
+		t.Run("should reject malformed configuration", func(t *testing.T) {
+			_, err := nodeRegistry.ApplyPatch(ctx, "test-node", []byte(`{`))
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+	})
+}
+
+func TestNodeRegistry_ApplyNode(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		t.Run("should require a field manager", func(t *testing.T) {
+			_, err := nodeRegistry.ApplyNode(ctx, "test-node", "", []byte(`{"metadata":{"name":"test-node"}}`))
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+
+		t.Run("should create a missing node and record its manager", func(t *testing.T) {
+			applied := `{"metadata":{"name":"test-node","labels":{"team":"infra","env":"prod"}}}`
+			node, err := nodeRegistry.ApplyNode(ctx, "test-node", "bootstrap", []byte(applied))
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"team": "infra", "env": "prod"}, node.Labels)
+
+			stored, err := nodeRegistry.GetNode(ctx, "test-node")
+			require.NoError(t, err)
+			managers, err := fieldManagers(stored)
+			require.NoError(t, err)
+			assert.JSONEq(t, applied, string(managers["bootstrap"]))
+		})
+
+		t.Run("should only remove fields the same manager applied", func(t *testing.T) {
+			_, err := nodeRegistry.ApplyNode(ctx, "test-node", "zoner", []byte(`{"metadata":{"name":"test-node","labels":{"zone":"a"}}}`))
+			require.NoError(t, err)
+
+			// bootstrap drops env; zone belongs to zoner and stays
+			node, err := nodeRegistry.ApplyNode(ctx, "test-node", "bootstrap",
+				[]byte(`{"metadata":{"name":"test-node","labels":{"team":"platform"}},"spec":{"unschedulable":true}}`))
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"team": "platform", "zone": "a"}, node.Labels)
+			assert.True(t, node.Spec.Unschedulable)
+
+			managers, err := fieldManagers(node)
+			require.NoError(t, err)
+			assert.Len(t, managers, 2)
+		})
+
+		t.Run("should reject a patch that leaves the node invalid", func(t *testing.T) {
+			_, err := nodeRegistry.ApplyNode(ctx, "test-node", "zoner", []byte(`{"metadata":{"name":"test-node","labels":{"zone":"not valid!"}}}`))
+			assert.ErrorIs(t, err, ErrPatchInvalid)
+
+			node, err := nodeRegistry.GetNode(ctx, "test-node")
+			require.NoError(t, err)
+			assert.Equal(t, "a", node.Labels["zone"])
+		})
+
+		t.Run("should reject configuration for another node", func(t *testing.T) {
+			_, err := nodeRegistry.ApplyNode(ctx, "test-node", "bootstrap", []byte(`{"metadata":{"name":"other-node"}}`))
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+	})
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/storage"
+)
+
+// bootstrapTokenPrefix is the storage key prefix for bootstrap tokens
+const bootstrapTokenPrefix = "/registry/bootstraptokens/"
+
+// Errors returned for bootstrap tokens that can't be stored
+var (
+	ErrTokenInvalid       = errors.New("invalid bootstrap token")
+	ErrTokenAlreadyExists = errors.New("bootstrap token already exists")
+)
+
+var (
+	bootstrapTokenIDPattern     = regexp.MustCompile(`^[a-z0-9]{6}$`)
+	bootstrapTokenSecretPattern = regexp.MustCompile(`^[a-z0-9]{16}$`)
+)
+
+// BootstrapTokenRegistry stores bootstrap tokens and authenticates the nodes that
+// present them
+type BootstrapTokenRegistry struct {
+	storage storage.Storage
+	clock   clock.Clock
+}
+
+// NewBootstrapTokenRegistry returns a BootstrapTokenRegistry that checks expiry
+// against c
+func NewBootstrapTokenRegistry(storage storage.Storage, c clock.Clock) *BootstrapTokenRegistry {
+	return &BootstrapTokenRegistry{storage: storage, clock: c}
+}
+
+// CreateToken stores a new bootstrap token. The ID must be 6 and the secret 16
+// lowercase letters or digits, and the token must expire and name the one node it
+// may register.
+func (r *BootstrapTokenRegistry) CreateToken(ctx context.Context, token *api.BootstrapToken) error {
+	if !bootstrapTokenIDPattern.MatchString(token.ID) || !bootstrapTokenSecretPattern.MatchString(token.Secret) {
+		return fmt.Errorf("%w: must be <6 lowercase alphanumerics>.<16 lowercase alphanumerics>", ErrTokenInvalid)
+	}
+	if token.Expiration.IsZero() {
+		return fmt.Errorf("%w: %s has no expiration", ErrTokenInvalid, token.ID)
+	}
+	if token.NodeName == "" {
+		return fmt.Errorf("%w: %s doesn't name the node it registers", ErrTokenInvalid, token.ID)
+	}
+
+	err := r.storage.Create(ctx, generateKey(bootstrapTokenPrefix, token.ID), token)
+	switch {
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return fmt.Errorf("%w: %s", ErrTokenAlreadyExists, token.ID)
+	case err != nil:
+		return fmt.Errorf("failed to store bootstrap token %s: %w", token.ID, err)
+	}
+	return nil
+}
+
+// AuthenticateToken implements api.TokenAuthenticator for tokens stored with
+// CreateToken
+func (r *BootstrapTokenRegistry) AuthenticateToken(ctx context.Context, bearer string) (*api.BootstrapToken, error) {
+	id, secret, _ := strings.Cut(bearer, ".")
+	if !bootstrapTokenIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: malformed bootstrap token", api.ErrUnauthorized)
+	}
+
+	token := &api.BootstrapToken{}
+	err := r.storage.Get(ctx, generateKey(bootstrapTokenPrefix, id), token)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown bootstrap token %s", api.ErrUnauthorized, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bootstrap token %s: %w", id, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(token.Secret)) != 1 {
+		return nil, fmt.Errorf("%w: unknown bootstrap token %s", api.ErrUnauthorized, id)
+	}
+	if !r.clock.Now().Before(token.Expiration) {
+		return nil, fmt.Errorf("%w: bootstrap token %s expired at %s", api.ErrUnauthorized, id, token.Expiration)
+	}
+	return token, nil
+}
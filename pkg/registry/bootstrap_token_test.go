@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestBootstrapTokenRegistry(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		ctx := context.Background()
+		fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		r := NewBootstrapTokenRegistry(storage.NewEtcdStorage(cli), fakeClock)
+
+		token := &api.BootstrapToken{
+			ID:         "abcdef",
+			Secret:     "0123456789abcdef",
+			Expiration: fakeClock.Now().Add(time.Hour),
+			NodeName:   "node-1",
+			Groups:     []string{api.BootstrapNodesGroup},
+		}
+		require.NoError(t, r.CreateToken(ctx, token))
+
+		t.Run("should authenticate a valid token", func(t *testing.T) {
+			got, err := r.AuthenticateToken(ctx, "abcdef.0123456789abcdef")
+			require.NoError(t, err)
+			assert.Equal(t, []string{api.BootstrapNodesGroup}, got.Groups)
+		})
+
+		t.Run("should reject unknown, malformed and wrong tokens", func(t *testing.T) {
+			for _, bearer := range []string{"abcdef.fedcba9876543210", "zzzzzz.0123456789abcdef", "abcdef", "not-a-token", ""} {
+				_, err := r.AuthenticateToken(ctx, bearer)
+				assert.ErrorIs(t, err, api.ErrUnauthorized, bearer)
+			}
+		})
+
+		t.Run("should reject an expired token", func(t *testing.T) {
+			fakeClock.Step(time.Hour)
+			_, err := r.AuthenticateToken(ctx, "abcdef.0123456789abcdef")
+			assert.ErrorIs(t, err, api.ErrUnauthorized)
+		})
+
+		t.Run("should only store well-formed tokens that expire and name a node", func(t *testing.T) {
+			expires := fakeClock.Now().Add(time.Hour)
+			for _, invalid := range []*api.BootstrapToken{
+				{ID: "ABCDEF", Secret: "0123456789abcdef", Expiration: expires, NodeName: "node-1"},
+				{ID: "abcdeg", Secret: "short", Expiration: expires, NodeName: "node-1"},
+				{ID: "abcdeh", Secret: "0123456789abcdef", NodeName: "node-1"},
+				{ID: "abcdei", Secret: "0123456789abcdef", Expiration: expires},
+			} {
+				assert.ErrorIs(t, r.CreateToken(ctx, invalid), ErrTokenInvalid, invalid.ID)
+			}
+			assert.ErrorIs(t, r.CreateToken(ctx, token), ErrTokenAlreadyExists)
+		})
+	})
+}
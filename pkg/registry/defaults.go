@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gokube/pkg/api"
+)
+
+// generatedNameSuffixLength is the number of random characters appended to GenerateName
+const generatedNameSuffixLength = 5
+
+// nameSuffixAlphabet avoids vowels and look-alike characters
+const nameSuffixAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+// CordonedByDefaulting is who Nodes cordoned by WithCordonedRoles are cordoned by
+const CordonedByDefaulting = "registry-defaulting"
+
+// defaultNodeOnCreate fills in server-side defaults for a node that is being created.
+// A node without a UID is given one by the registry's uid.Generator.
+// A node that hasn't reported a status yet starts as Unknown rather than appearing
+// Ready before its kubelet has checked in. A creation timestamp that is already set,
+// e.g. by ImportNodes restoring a backup, is preserved. The registry's default labels
+// are added but never override a label the client set, even to an empty value. A
+// node with a role the registry cordons by default is cordoned, unless the client
+// already cordoned it.
+func (r *NodeRegistry) defaultNodeOnCreate(node *api.Node) {
+	if node.Name == "" && node.GenerateName != "" {
+		node.Name = r.nameGenerator.GenerateName(node.GenerateName)
+	}
+	node.Name = r.normalizeName(node.Name)
+	if node.UID == "" {
+		node.UID = r.uidGenerator.NewUID()
+	}
+	if node.Status == "" {
+		node.Status = api.NodeUnknown
+	}
+	if node.CreationTimestamp.IsZero() {
+		node.CreationTimestamp = r.clock.Now()
+	}
+	if node.LastModified.IsZero() {
+		node.LastModified = node.CreationTimestamp
+	}
+	for key, value := range r.defaultLabels {
+		if _, ok := node.Labels[key]; ok {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[key] = value
+	}
+	r.defaultCordon(node)
+}
+
+// defaultCordon cordons node if it has one of the registry's cordoned roles,
+// recording the role as the reason
+func (r *NodeRegistry) defaultCordon(node *api.Node) {
+	if node.Spec.Unschedulable {
+		return
+	}
+	for _, role := range node.Roles() {
+		if !slices.Contains(r.cordonedRoles, role) {
+			continue
+		}
+		node.Spec.Unschedulable = true
+		node.Spec.CordonReason = fmt.Sprintf("%s nodes are cordoned by default", role)
+		node.Spec.CordonedBy = CordonedByDefaulting
+		node.Spec.CordonedAt = node.CreationTimestamp
+		return
+	}
+}
+
+// normalizeName returns name as the registry stores it. With name normalization
+// enabled that is lowercase without trailing dots; it must be applied before the
+// name is turned into a storage key.
+func (r *NodeRegistry) normalizeName(name string) string {
+	if !r.normalizeNames {
+		return name
+	}
+	return strings.ToLower(strings.TrimRight(name, "."))
+}
+
+// randomNameGenerator is the default names.NameGenerator, appending a
+// randomNameSuffix to the base name
+type randomNameGenerator struct{}
+
+func (randomNameGenerator) GenerateName(base string) string {
+	return base + randomNameSuffix()
+}
+
+// randomNameSuffix returns generatedNameSuffixLength random characters for GenerateName
+func randomNameSuffix() string {
+	b := make([]byte, generatedNameSuffixLength)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = nameSuffixAlphabet[int(b[i])%len(nameSuffixAlphabet)]
+	}
+	return string(b)
+}
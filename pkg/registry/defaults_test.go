@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/registry/names"
+	"gokube/pkg/registry/uid"
+	"gokube/pkg/storage"
+)
+
+func TestNodeRegistry_CreateNodeDefaults(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		t.Run("should default a node without status to Unknown", func(t *testing.T) {
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-1", "1")
+
+			node, err := nodeRegistry.GetNode(ctx, "test-node-1")
+			require.NoError(t, err)
+			assert.Equal(t, api.NodeUnknown, node.Status)
+			assert.False(t, node.Spec.Unschedulable)
+		})
+
+		t.Run("should generate a name from generateName", func(t *testing.T) {
+			node := &api.Node{ObjectMeta: api.ObjectMeta{GenerateName: "worker-"}}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			assert.True(t, strings.HasPrefix(node.Name, "worker-"))
+			assert.Len(t, node.Name, len("worker-")+generatedNameSuffixLength)
+			_, err := nodeRegistry.GetNode(ctx, node.Name)
+			assert.NoError(t, err)
+		})
+
+		t.Run("should generate predictable names with a fake generator", func(t *testing.T) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithNameGenerator(names.NewFakeNameGenerator()))
+
+			for _, want := range []string{"fake-00001", "fake-00002"} {
+				node := &api.Node{ObjectMeta: api.ObjectMeta{GenerateName: "fake-"}}
+				require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+				assert.Equal(t, want, node.Name)
+			}
+		})
+
+		t.Run("should give a node without a UID one from the generator", func(t *testing.T) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithUIDGenerator(uid.NewFakeGenerator()))
+
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "uid-node-1"}}))
+			require.NoError(t, nodeRegistry.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "uid-node-2", UID: "restored"}}))
+
+			node, err := nodeRegistry.GetNode(ctx, "uid-node-1")
+			require.NoError(t, err)
+			assert.Equal(t, "00000000-0000-0000-0000-000000000001", node.UID)
+			node, err = nodeRegistry.GetNode(ctx, "uid-node-2")
+			require.NoError(t, err)
+			assert.Equal(t, "restored", node.UID)
+		})
+
+		t.Run("should set the creation timestamp from the clock", func(t *testing.T) {
+			fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithClock(fakeClock))
+
+			fakeClock.Step(time.Hour)
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-3", "3")
+
+			node, err := nodeRegistry.GetNode(ctx, "test-node-3")
+			require.NoError(t, err)
+			assert.True(t, fakeClock.Now().Equal(node.CreationTimestamp))
+		})
+
+		t.Run("should keep a status supplied by the client", func(t *testing.T) {
+			node := createTestNode("test-node-2", "2")
+			node.Status = api.NodeReady
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			node, err := nodeRegistry.GetNode(ctx, "test-node-2")
+			require.NoError(t, err)
+			assert.Equal(t, api.NodeReady, node.Status)
+		})
+
+		t.Run("should add default labels without overriding the client's", func(t *testing.T) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer),
+				WithDefaultLabels(map[string]string{"kubernetes.io/os": "linux"}))
+
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-4", "4")
+			node, err := nodeRegistry.GetNode(ctx, "test-node-4")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"kubernetes.io/os": "linux"}, node.Labels)
+
+			node = createTestNode("test-node-5", "5")
+			node.Labels = map[string]string{"kubernetes.io/os": "windows"}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+			node, err = nodeRegistry.GetNode(ctx, "test-node-5")
+			require.NoError(t, err)
+			assert.Equal(t, "windows", node.Labels["kubernetes.io/os"])
+		})
+
+		t.Run("should normalize names consistently when enabled", func(t *testing.T) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithNameNormalization())
+
+			createTestNodeInRegistry(t, nodeRegistry, "Worker-1.", "6")
+
+			for _, name := range []string{"worker-1", "Worker-1.", "WORKER-1"} {
+				node, err := nodeRegistry.GetNode(ctx, name)
+				require.NoError(t, err, name)
+				assert.Equal(t, "worker-1", node.Name)
+			}
+
+			node := createTestNode("WORKER-1", "6")
+			err := nodeRegistry.CreateNode(ctx, node)
+			assert.ErrorIs(t, err, ErrNodeAlreadyExists)
+
+			require.NoError(t, nodeRegistry.DeleteNode(ctx, "Worker-1."))
+			exists, err := nodeRegistry.NodeExists(ctx, "worker-1")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+
+		t.Run("should cordon control-plane nodes but not workers", func(t *testing.T) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithCordonedRoles(api.NodeRoleControlPlane))
+
+			controlPlane := createTestNode("test-node-7", "7")
+			controlPlane.Labels = map[string]string{api.LabelNodeRolePrefix + api.NodeRoleControlPlane: ""}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, controlPlane))
+			worker := createTestNode("test-node-8", "8")
+			worker.Labels = map[string]string{api.LabelNodeRolePrefix + "worker": ""}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, worker))
+
+			s, err := nodeRegistry.GetNodeSchedulability(ctx, "test-node-7")
+			require.NoError(t, err)
+			assert.True(t, s.Unschedulable)
+			assert.Equal(t, "control-plane nodes are cordoned by default", s.Reason)
+			assert.Equal(t, CordonedByDefaulting, s.By)
+
+			s, err = nodeRegistry.GetNodeSchedulability(ctx, "test-node-8")
+			require.NoError(t, err)
+			assert.False(t, s.Unschedulable)
+		})
+
+		t.Run("should keep a cordon the client set", func(t *testing.T) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithCordonedRoles(api.NodeRoleControlPlane))
+
+			node := createTestNode("test-node-9", "9")
+			node.Labels = map[string]string{api.LabelNodeRolePrefix + api.NodeRoleControlPlane: ""}
+			node.Spec.Unschedulable = true
+			node.Spec.CordonReason = "maintenance"
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			s, err := nodeRegistry.GetNodeSchedulability(ctx, "test-node-9")
+			require.NoError(t, err)
+			assert.Equal(t, "maintenance", s.Reason)
+		})
+
+		t.Run("should not cordon any role by default", func(t *testing.T) {
+			node := createTestNode("test-node-10", "10")
+			node.Labels = map[string]string{api.LabelNodeRolePrefix + api.NodeRoleControlPlane: ""}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			node, err := nodeRegistry.GetNode(ctx, "test-node-10")
+			require.NoError(t, err)
+			assert.False(t, node.Spec.Unschedulable)
+		})
+	})
+}
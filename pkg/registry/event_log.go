@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/watch"
+)
+
+// LoggedEvent is a Node mutation as recorded in an EventLog
+type LoggedEvent struct {
+	// Revision numbers the events of a log in the order they were appended, from 1
+	Revision int64           `json:"revision"`
+	Type     watch.EventType `json:"type"`
+	Node     *api.Node       `json:"node"`
+	// Time is when the event was appended; events logged before it was recorded
+	// have none
+	Time time.Time `json:"time,omitempty"`
+}
+
+// EventLog durably records Node mutations so they can be replayed after a restart
+type EventLog interface {
+	// Append records an event and returns the revision it was assigned
+	Append(ctx context.Context, eventType watch.EventType, node *api.Node) (int64, error)
+	// Replay calls fn, in order, for every event with a revision greater than fromRevision
+	Replay(ctx context.Context, fromRevision int64, fn func(event LoggedEvent) error) error
+}
+
+// WithEventLog records every Node mutation made through the registry in eventLog
+func WithEventLog(eventLog EventLog) Option {
+	return func(r *NodeRegistry) {
+		r.eventLog = eventLog
+	}
+}
+
+// ReplayEvents calls fn for every logged Node mutation after fromRevision, oldest
+// first. Replaying from revision 0 and applying each event in turn reproduces the
+// Nodes in storage.
+func (r *NodeRegistry) ReplayEvents(ctx context.Context, fromRevision int64, fn func(event LoggedEvent) error) error {
+	if r.eventLog == nil {
+		return fmt.Errorf("%w: no event log configured", ErrInternal)
+	}
+	return r.eventLog.Replay(ctx, fromRevision, fn)
+}
+
+// notify logs a mutation that has been written to storage and sends it to watchers.
+// The write has already happened, so a failure to log it is reported but doesn't
+// fail the operation.
+func (r *NodeRegistry) notify(ctx context.Context, eventType watch.EventType, node *api.Node) {
+	if r.eventLog != nil {
+		if _, err := r.eventLog.Append(ctx, eventType, node); err != nil {
+			log.Printf("Error logging %s event for node %s: %v request_id=%s", eventType, node.Name, err, api.RequestIDFrom(ctx))
+		}
+	}
+	r.revision.Add(1)
+	r.broadcaster.Action(eventType, node)
+}
+
+// FileEventLog is an EventLog kept in a file as one JSON object per line
+type FileEventLog struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	revision int64
+	clock    clock.Clock
+}
+
+// FileEventLogOption configures optional FileEventLog behaviour
+type FileEventLogOption func(*FileEventLog)
+
+// WithEventLogClock sets the clock events are timestamped with, defaulting to the
+// real clock
+func WithEventLogClock(c clock.Clock) FileEventLogOption {
+	return func(l *FileEventLog) {
+		l.clock = c
+	}
+}
+
+// OpenFileEventLog opens the event log at path, creating it if it doesn't exist.
+// New events are numbered after the ones already in the file.
+func OpenFileEventLog(path string, opts ...FileEventLogOption) (*FileEventLog, error) {
+	l := &FileEventLog{path: path, clock: clock.RealClock{}}
+	for _, opt := range opts {
+		opt(l)
+	}
+	err := l.Replay(context.Background(), 0, func(event LoggedEvent) error {
+		l.revision = event.Revision
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	l.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return l, nil
+}
+
+// Append implements EventLog. Every event is synced to disk before it is acknowledged.
+func (l *FileEventLog) Append(_ context.Context, eventType watch.EventType, node *api.Node) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	event := LoggedEvent{Revision: l.revision + 1, Type: eventType, Node: node, Time: l.clock.Now()}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to write event: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync event log: %w", err)
+	}
+
+	l.revision = event.Revision
+	return event.Revision, nil
+}
+
+// Replay implements EventLog. Events appended while replaying may or may not be seen.
+func (l *FileEventLog) Replay(ctx context.Context, fromRevision int64, fn func(event LoggedEvent) error) error {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A partial last line is an append cut short by a crash
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read event log: %w", err)
+		}
+
+		var event LoggedEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("corrupt event log entry: %w", err)
+		}
+		if event.Revision <= fromRevision {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the log file
+func (l *FileEventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/storage"
+	"gokube/pkg/watch"
+)
+
+func TestNodeRegistry_ReplayEvents(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		path := filepath.Join(t.TempDir(), "events.log")
+		eventLog, err := OpenFileEventLog(path)
+		require.NoError(t, err)
+		defer eventLog.Close()
+
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithEventLog(eventLog))
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			createTestNodeInRegistry(t, nodeRegistry, fmt.Sprintf("test-node-%d", i), fmt.Sprintf("%d", i))
+		}
+		node, err := nodeRegistry.GetNode(ctx, "test-node-1")
+		require.NoError(t, err)
+		node.Spec.Unschedulable = true
+		require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+		require.NoError(t, nodeRegistry.DeleteNode(ctx, "test-node-2"))
+
+		t.Run("should reproduce the current nodes when replayed from revision 0", func(t *testing.T) {
+			replayed := map[string]*api.Node{}
+			var revisions []int64
+			err := nodeRegistry.ReplayEvents(ctx, 0, func(event LoggedEvent) error {
+				revisions = append(revisions, event.Revision)
+				if event.Type == watch.Deleted {
+					delete(replayed, event.Node.Name)
+				} else {
+					replayed[event.Node.Name] = event.Node
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{1, 2, 3, 4, 5}, revisions)
+
+			nodes, err := nodeRegistry.ListNodes(ctx)
+			require.NoError(t, err)
+			require.Len(t, replayed, len(nodes))
+			for _, node := range nodes {
+				require.Contains(t, replayed, node.Name)
+				assert.Equal(t, node.Spec, replayed[node.Name].Spec)
+				assert.True(t, node.LastModified.Equal(replayed[node.Name].LastModified))
+			}
+		})
+
+		t.Run("should skip events up to fromRevision", func(t *testing.T) {
+			var types []watch.EventType
+			err := nodeRegistry.ReplayEvents(ctx, 3, func(event LoggedEvent) error {
+				types = append(types, event.Type)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []watch.EventType{watch.Modified, watch.Deleted}, types)
+		})
+
+		t.Run("should continue numbering after reopening the log", func(t *testing.T) {
+			reopened, err := OpenFileEventLog(path)
+			require.NoError(t, err)
+			defer reopened.Close()
+
+			revision, err := reopened.Append(ctx, watch.Added, createTestNode("test-node-3", "3"))
+			require.NoError(t, err)
+			assert.Equal(t, int64(6), revision)
+		})
+	})
+}
+
+// failingEventLog fails every Append
+type failingEventLog struct{}
+
+func (failingEventLog) Append(context.Context, watch.EventType, *api.Node) (int64, error) {
+	return 0, errors.New("disk full")
+}
+
+func (failingEventLog) Replay(context.Context, int64, func(event LoggedEvent) error) error {
+	return nil
+}
+
+func TestNodeRegistry_EventLogFailure(t *testing.T) {
+	t.Run("should log the request ID with the failure", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithEventLog(failingEventLog{}))
+			ctx := api.WithRequestID(context.Background(), "req-123")
+
+			// The node is still created; only the log entry is lost
+			require.NoError(t, nodeRegistry.CreateNode(ctx, createTestNode("test-node-1", "1")))
+			assert.Contains(t, logs.String(), "disk full request_id=req-123")
+		})
+	})
+}
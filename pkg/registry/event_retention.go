@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gokube/pkg/clock"
+	"gokube/pkg/watch"
+)
+
+// The kinds of logged events a RetentionPolicy purges
+const (
+	PurgedEvents     = "event"
+	PurgedTombstones = "tombstone"
+)
+
+// RetentionPolicy says how long an event log keeps events. A zero TTL keeps that
+// kind of event forever.
+type RetentionPolicy struct {
+	// EventTTL is how long an event is kept once a later event of the same Node
+	// has replaced it
+	EventTTL time.Duration
+	// TombstoneTTL is how long the Deleted event of a Node, and with it everything
+	// logged about the Node before, is kept
+	TombstoneTTL time.Duration
+}
+
+// PurgeResult counts the events a purge removed, by kind
+type PurgeResult map[string]int
+
+// Purge removes the events policy no longer keeps, as of now. Only events that no
+// longer affect the outcome of a replay are removed, so replaying from revision 0
+// still reproduces the current Nodes. The last event is always kept, so that events
+// appended after a restart keep being numbered after it; events without a Time are
+// kept too.
+func (l *FileEventLog) Purge(ctx context.Context, policy RetentionPolicy, now time.Time) (PurgeResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []LoggedEvent
+	if err := l.Replay(ctx, 0, func(event LoggedEvent) error {
+		events = append(events, event)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	expired := func(event LoggedEvent, ttl time.Duration) bool {
+		return ttl > 0 && !event.Time.IsZero() && now.Sub(event.Time) >= ttl
+	}
+
+	// Walk backwards so that every event already knows what comes after it
+	result := PurgeResult{}
+	purge := make([]bool, len(events))
+	replaced := map[string]bool{}
+	tombstoned := map[string]bool{}
+	for i := len(events) - 2; i >= 0; i-- {
+		next, event := events[i+1], events[i]
+		replaced[next.Node.Name] = true
+		if next.Type == watch.Deleted && expired(next, policy.TombstoneTTL) {
+			tombstoned[next.Node.Name] = true
+		}
+
+		switch {
+		case tombstoned[event.Node.Name]:
+			purge[i] = true
+			result[PurgedTombstones]++
+		case event.Type == watch.Deleted && expired(event, policy.TombstoneTTL):
+			purge[i] = true
+			tombstoned[event.Node.Name] = true
+			result[PurgedTombstones]++
+		case replaced[event.Node.Name] && expired(event, policy.EventTTL):
+			purge[i] = true
+			result[PurgedEvents]++
+		}
+	}
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	var kept []LoggedEvent
+	for i, event := range events {
+		if !purge[i] {
+			kept = append(kept, event)
+		}
+	}
+	if err := l.rewrite(kept); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rewrite replaces the log file with events, atomically. The caller must hold l.mu.
+func (l *FileEventLog) rewrite(events []LoggedEvent) error {
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite event log: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	encoder := json.NewEncoder(f)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to rewrite event log: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync event log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite event log: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to rewrite event log: %w", err)
+	}
+
+	// Appends must go to the new file, not the one that was replaced
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	l.file.Close()
+	l.file = file
+	return nil
+}
+
+// RunEventLogPurger purges l according to policy every interval until ctx is done,
+// passing the number of events purged of each kind to observe, if set. Failed
+// purges are logged and retried at the next tick.
+func RunEventLogPurger(ctx context.Context, l *FileEventLog, interval time.Duration, policy RetentionPolicy, c clock.Clock, observe func(kind string, purged int)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := l.Purge(ctx, policy, c.Now())
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Error purging event log: %v", err)
+				}
+				continue
+			}
+			if observe != nil {
+				for kind, purged := range result {
+					observe(kind, purged)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/storage"
+	"gokube/pkg/watch"
+)
+
+func TestFileEventLog_Purge(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		path := filepath.Join(t.TempDir(), "events.log")
+		eventLog, err := OpenFileEventLog(path, WithEventLogClock(fakeClock))
+		require.NoError(t, err)
+		defer eventLog.Close()
+
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithEventLog(eventLog), WithClock(fakeClock))
+		ctx := context.Background()
+
+		// Two hours ago: test-node-1 is created and test-node-2 created and deleted
+		createTestNodeInRegistry(t, nodeRegistry, "test-node-1", "1")
+		createTestNodeInRegistry(t, nodeRegistry, "test-node-2", "2")
+		require.NoError(t, nodeRegistry.DeleteNode(ctx, "test-node-2"))
+
+		// Now: test-node-1 is updated
+		fakeClock.Step(2 * time.Hour)
+		node, err := nodeRegistry.GetNode(ctx, "test-node-1")
+		require.NoError(t, err)
+		node.Spec.Unschedulable = true
+		require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+		createTestNodeInRegistry(t, nodeRegistry, "test-node-3", "3")
+
+		policy := RetentionPolicy{EventTTL: time.Hour, TombstoneTTL: time.Hour}
+		result, err := eventLog.Purge(ctx, policy, fakeClock.Now())
+		require.NoError(t, err)
+		assert.Equal(t, PurgeResult{PurgedEvents: 1, PurgedTombstones: 2}, result)
+
+		t.Run("should keep fresh events and the latest event of every node", func(t *testing.T) {
+			var kept []LoggedEvent
+			require.NoError(t, eventLog.Replay(ctx, 0, func(event LoggedEvent) error {
+				kept = append(kept, event)
+				return nil
+			}))
+			require.Len(t, kept, 2)
+			assert.Equal(t, int64(4), kept[0].Revision)
+			assert.Equal(t, watch.Modified, kept[0].Type)
+			assert.Equal(t, "test-node-1", kept[0].Node.Name)
+			assert.Equal(t, "test-node-3", kept[1].Node.Name)
+		})
+
+		t.Run("should keep numbering appends after a purge", func(t *testing.T) {
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-4", "4")
+			reopened, err := OpenFileEventLog(path)
+			require.NoError(t, err)
+			defer reopened.Close()
+
+			var revisions []int64
+			require.NoError(t, reopened.Replay(ctx, 0, func(event LoggedEvent) error {
+				revisions = append(revisions, event.Revision)
+				return nil
+			}))
+			assert.Equal(t, []int64{4, 5, 6}, revisions)
+		})
+
+		t.Run("should purge nothing that is still fresh", func(t *testing.T) {
+			result, err := eventLog.Purge(ctx, policy, fakeClock.Now())
+			require.NoError(t, err)
+			assert.Empty(t, result)
+		})
+	})
+}
+
+func TestRetentionPolicy_ZeroTTL(t *testing.T) {
+	t.Run("should keep everything when no TTL is set", func(t *testing.T) {
+		fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		eventLog, err := OpenFileEventLog(filepath.Join(t.TempDir(), "events.log"), WithEventLogClock(fakeClock))
+		require.NoError(t, err)
+		defer eventLog.Close()
+
+		ctx := context.Background()
+		node := &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}
+		for _, eventType := range []watch.EventType{watch.Added, watch.Modified, watch.Deleted, watch.Added} {
+			_, err := eventLog.Append(ctx, eventType, node)
+			require.NoError(t, err)
+		}
+
+		result, err := eventLog.Purge(ctx, RetentionPolicy{}, fakeClock.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+}
@@ -0,0 +1,546 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/labels"
+	"gokube/pkg/runtime"
+	"gokube/pkg/watch"
+)
+
+// Action is a call made to a FakeNodeRegistry
+type Action struct {
+	// Method is the NodeInterface method that was called, e.g. "GetNode"
+	Method string
+	// Name is the Node the call was about, if it was about a single Node
+	Name string
+	// Object is the Node passed to the call, if any
+	Object *api.Node
+}
+
+// ReactionFunc decides how a FakeNodeRegistry answers action. If handled is false
+// the next reactor is asked. Otherwise ret and err are returned, ret converted to the
+// method's result type; a nil ret or one of another type returns the zero value.
+type ReactionFunc func(action Action) (handled bool, ret any, err error)
+
+type reactor struct {
+	method string
+	name   string
+	react  ReactionFunc
+}
+
+// matches reports whether r reacts to action; "*" matches any method or name
+func (r reactor) matches(action Action) bool {
+	return (r.method == "*" || r.method == action.Method) && (r.name == "*" || r.name == action.Name)
+}
+
+// FakeNodeRegistry is a NodeInterface for tests, in the manner of the client-go
+// fakes. It records every call as an Action and lets reactors answer them. Calls no
+// reactor handles are served from an in-memory set of Nodes: creating, reading,
+// updating, deleting, listing and watching Nodes work as with a NodeRegistry, minus
+// defaulting, validation and admission. All other methods return zero values.
+// Reactors are called without any lock held, so they may call the fake.
+type FakeNodeRegistry struct {
+	mu       sync.Mutex
+	actions  []Action
+	reactors []reactor
+
+	nodes       map[string]*api.Node
+	revision    uint64
+	broadcaster *watch.Broadcaster
+}
+
+// NewFakeNodeRegistry returns a FakeNodeRegistry holding copies of nodes
+func NewFakeNodeRegistry(nodes ...*api.Node) *FakeNodeRegistry {
+	f := &FakeNodeRegistry{
+		nodes:       make(map[string]*api.Node),
+		broadcaster: watch.NewBroadcaster(0),
+	}
+	for _, node := range nodes {
+		f.nodes[node.Name] = copyNode(node)
+	}
+	return f
+}
+
+// AddReactor makes react answer calls of method about the Node called name. Either
+// may be "*" to match any. Reactors are asked in the order they were added.
+func (f *FakeNodeRegistry) AddReactor(method, name string, react ReactionFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reactors = append(f.reactors, reactor{method: method, name: name, react: react})
+}
+
+// Actions returns the calls made so far, oldest first
+func (f *FakeNodeRegistry) Actions() []Action {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Action(nil), f.actions...)
+}
+
+// ClearActions forgets the calls made so far
+func (f *FakeNodeRegistry) ClearActions() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.actions = nil
+}
+
+// invoke records action and returns the answer of the first reactor that handles
+// it, or of fallback if none does. A nil fallback returns zero values.
+func (f *FakeNodeRegistry) invoke(action Action, fallback func() (any, error)) (any, error) {
+	f.mu.Lock()
+	f.actions = append(f.actions, action)
+	reactors := append([]reactor(nil), f.reactors...)
+	f.mu.Unlock()
+
+	for _, r := range reactors {
+		if !r.matches(action) {
+			continue
+		}
+		if handled, ret, err := r.react(action); handled {
+			return ret, err
+		}
+	}
+	if fallback == nil {
+		return nil, nil
+	}
+	return fallback()
+}
+
+// copyNode returns a deep copy of node, so that callers can't change stored Nodes
+func copyNode(node *api.Node) *api.Node {
+	data, err := runtime.Encode(node)
+	if err != nil {
+		panic(fmt.Sprintf("registry: failed to copy node: %v", err))
+	}
+	copied := &api.Node{}
+	if err := runtime.Decode(data, copied); err != nil {
+		panic(fmt.Sprintf("registry: failed to copy node: %v", err))
+	}
+	return copied
+}
+
+// notify records a mutation and tells watchers about it. The caller must hold f.mu.
+func (f *FakeNodeRegistry) notify(eventType watch.EventType, node *api.Node) {
+	f.revision++
+	f.broadcaster.Action(eventType, copyNode(node))
+}
+
+func (f *FakeNodeRegistry) create(node *api.Node) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if node.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrNodeInvalid)
+	}
+	if _, ok := f.nodes[node.Name]; ok {
+		return ErrNodeAlreadyExists
+	}
+	f.nodes[node.Name] = copyNode(node)
+	f.notify(watch.Added, node)
+	return nil
+}
+
+func (f *FakeNodeRegistry) get(name string) (*api.Node, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.nodes[name]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	return copyNode(node), nil
+}
+
+func (f *FakeNodeRegistry) update(node *api.Node) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.nodes[node.Name]; !ok {
+		return ErrNodeNotFound
+	}
+	f.nodes[node.Name] = copyNode(node)
+	f.notify(watch.Modified, node)
+	return nil
+}
+
+// delete removes the Node called name, reporting whether it existed
+func (f *FakeNodeRegistry) delete(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.nodes[name]
+	if !ok {
+		return false
+	}
+	delete(f.nodes, name)
+	f.notify(watch.Deleted, node)
+	return true
+}
+
+// list returns copies of the Nodes matching filter, sorted by name like a
+// NodeRegistry lists them, and the number of Nodes before filtering
+func (f *FakeNodeRegistry) list(filter NodeFilter) ([]*api.Node, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	nodes := make([]*api.Node, 0, len(f.nodes))
+	for _, node := range f.nodes {
+		if filter.Matches(node) {
+			nodes = append(nodes, copyNode(node))
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, len(f.nodes)
+}
+
+// watch starts watching, stopping the watch when ctx is done
+func (f *FakeNodeRegistry) watch(ctx context.Context) watch.Interface {
+	w := f.broadcaster.Watch()
+	go func() {
+		<-ctx.Done()
+		w.Stop()
+	}()
+	return w
+}
+
+// CreateNode implements NodeInterface
+func (f *FakeNodeRegistry) CreateNode(_ context.Context, node *api.Node) error {
+	_, err := f.invoke(Action{Method: "CreateNode", Name: node.Name, Object: node}, func() (any, error) {
+		return nil, f.create(node)
+	})
+	return err
+}
+
+// GetNode implements NodeInterface
+func (f *FakeNodeRegistry) GetNode(_ context.Context, name string) (*api.Node, error) {
+	ret, err := f.invoke(Action{Method: "GetNode", Name: name}, func() (any, error) {
+		return f.get(name)
+	})
+	node, _ := ret.(*api.Node)
+	return node, err
+}
+
+// NodeExists implements NodeInterface
+func (f *FakeNodeRegistry) NodeExists(_ context.Context, name string) (bool, error) {
+	ret, err := f.invoke(Action{Method: "NodeExists", Name: name}, func() (any, error) {
+		_, err := f.get(name)
+		if errors.Is(err, ErrNodeNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	exists, _ := ret.(bool)
+	return exists, err
+}
+
+// UpdateNode implements NodeInterface
+func (f *FakeNodeRegistry) UpdateNode(_ context.Context, node *api.Node) error {
+	_, err := f.invoke(Action{Method: "UpdateNode", Name: node.Name, Object: node}, func() (any, error) {
+		return nil, f.update(node)
+	})
+	return err
+}
+
+// UpdateNodeIfUnmodifiedSince implements NodeInterface. Unless a reactor handles
+// it, the precondition is not checked.
+func (f *FakeNodeRegistry) UpdateNodeIfUnmodifiedSince(_ context.Context, node *api.Node, _ time.Time) error {
+	_, err := f.invoke(Action{Method: "UpdateNodeIfUnmodifiedSince", Name: node.Name, Object: node}, func() (any, error) {
+		return nil, f.update(node)
+	})
+	return err
+}
+
+// UpdateNodeWithRetry implements NodeInterface
+func (f *FakeNodeRegistry) UpdateNodeWithRetry(_ context.Context, name string, mutate func(node *api.Node) error) error {
+	_, err := f.invoke(Action{Method: "UpdateNodeWithRetry", Name: name}, func() (any, error) {
+		node, err := f.get(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := mutate(node); err != nil {
+			return nil, err
+		}
+		return nil, f.update(node)
+	})
+	return err
+}
+
+// DeleteNode implements NodeInterface
+func (f *FakeNodeRegistry) DeleteNode(_ context.Context, name string) error {
+	_, err := f.invoke(Action{Method: "DeleteNode", Name: name}, func() (any, error) {
+		f.delete(name)
+		return nil, nil
+	})
+	return err
+}
+
+// DeleteNodesByName implements NodeInterface
+func (f *FakeNodeRegistry) DeleteNodesByName(_ context.Context, names []string) []DeleteResult {
+	ret, _ := f.invoke(Action{Method: "DeleteNodesByName"}, func() (any, error) {
+		results := make([]DeleteResult, 0, len(names))
+		for _, name := range names {
+			status := DeleteStatusNotFound
+			if f.delete(name) {
+				status = DeleteStatusDeleted
+			}
+			results = append(results, DeleteResult{Name: name, Status: status})
+		}
+		return results, nil
+	})
+	results, _ := ret.([]DeleteResult)
+	return results
+}
+
+// ListNodes implements NodeInterface
+func (f *FakeNodeRegistry) ListNodes(_ context.Context) ([]*api.Node, error) {
+	ret, err := f.invoke(Action{Method: "ListNodes"}, func() (any, error) {
+		nodes, _ := f.list(NodeFilter{})
+		return nodes, nil
+	})
+	nodes, _ := ret.([]*api.Node)
+	return nodes, err
+}
+
+// StreamNodes implements NodeInterface. A reactor answers it with the
+// []*api.Node to stream.
+func (f *FakeNodeRegistry) StreamNodes(ctx context.Context, fn func(node *api.Node) error) error {
+	nodes, err := f.invokeList(Action{Method: "StreamNodes"}, NodeFilter{})
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListNodesByPhase implements NodeInterface
+func (f *FakeNodeRegistry) ListNodesByPhase(_ context.Context, phase api.NodePhase) ([]*api.Node, error) {
+	return f.invokeList(Action{Method: "ListNodesByPhase"}, NodeFilter{Phase: phase})
+}
+
+// ListNodesByVersionSkew implements NodeInterface
+func (f *FakeNodeRegistry) ListNodesByVersionSkew(_ context.Context, _, _ string) ([]*api.Node, error) {
+	ret, err := f.invoke(Action{Method: "ListNodesByVersionSkew"}, nil)
+	nodes, _ := ret.([]*api.Node)
+	return nodes, err
+}
+
+// FilterNodes implements NodeInterface. A reactor answers it with the
+// []*api.Node that matched, which is also taken as the total.
+func (f *FakeNodeRegistry) FilterNodes(_ context.Context, filter NodeFilter) ([]*api.Node, int, error) {
+	total := 0
+	ret, err := f.invoke(Action{Method: "FilterNodes"}, func() (any, error) {
+		var nodes []*api.Node
+		nodes, total = f.list(filter)
+		return nodes, nil
+	})
+	nodes, _ := ret.([]*api.Node)
+	if total == 0 {
+		total = len(nodes)
+	}
+	return nodes, total, err
+}
+
+// invokeList invokes action, listing the Nodes matching filter unless a reactor
+// handles it
+func (f *FakeNodeRegistry) invokeList(action Action, filter NodeFilter) ([]*api.Node, error) {
+	ret, err := f.invoke(action, func() (any, error) {
+		nodes, _ := f.list(filter)
+		return nodes, nil
+	})
+	nodes, _ := ret.([]*api.Node)
+	return nodes, err
+}
+
+// WatchNodes implements NodeInterface. A reactor answers it with a
+// watch.Interface, e.g. a watch.Broadcaster's.
+func (f *FakeNodeRegistry) WatchNodes(ctx context.Context) (watch.Interface, error) {
+	ret, err := f.invoke(Action{Method: "WatchNodes"}, func() (any, error) {
+		return f.watch(ctx), nil
+	})
+	w, _ := ret.(watch.Interface)
+	return w, err
+}
+
+// WatchNodesWithInitialEvents implements NodeInterface
+func (f *FakeNodeRegistry) WatchNodesWithInitialEvents(ctx context.Context) (watch.Interface, error) {
+	ret, err := f.invoke(Action{Method: "WatchNodesWithInitialEvents"}, func() (any, error) {
+		live := f.watch(ctx)
+		nodes, _ := f.list(NodeFilter{})
+
+		initial := make([]watch.Event, 0, len(nodes)+1)
+		for _, node := range nodes {
+			initial = append(initial, watch.Event{Type: watch.Added, Object: node})
+		}
+		initial = append(initial, watch.Event{Type: watch.Bookmark, Object: &api.Node{
+			ObjectMeta: api.ObjectMeta{Annotations: map[string]string{watch.InitialEventsEndAnnotation: "true"}},
+		}})
+		return watch.Prepend(live, initial), nil
+	})
+	w, _ := ret.(watch.Interface)
+	return w, err
+}
+
+// WatchNode implements NodeInterface
+func (f *FakeNodeRegistry) WatchNode(ctx context.Context, name string) (watch.Interface, error) {
+	ret, err := f.invoke(Action{Method: "WatchNode", Name: name}, func() (any, error) {
+		live := watch.Filter(f.watch(ctx), func(event watch.Event) bool {
+			return nodeName(event.Object) == name
+		})
+
+		initial := watch.Event{Type: watch.Added}
+		node, err := f.get(name)
+		if err != nil {
+			initial.Type = watch.Deleted
+			node = &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}
+		}
+		initial.Object = node
+		return watch.Prepend(live, []watch.Event{initial}), nil
+	})
+	w, _ := ret.(watch.Interface)
+	return w, err
+}
+
+// Revision implements NodeInterface. Unless a reactor handles it, it counts the
+// Nodes created, updated and deleted.
+func (f *FakeNodeRegistry) Revision() uint64 {
+	ret, _ := f.invoke(Action{Method: "Revision"}, func() (any, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.revision, nil
+	})
+	revision, _ := ret.(uint64)
+	return revision
+}
+
+// WaitForChange implements NodeInterface
+func (f *FakeNodeRegistry) WaitForChange(_ context.Context, _ uint64) (bool, error) {
+	ret, err := f.invoke(Action{Method: "WaitForChange"}, nil)
+	changed, _ := ret.(bool)
+	return changed, err
+}
+
+// ApplyPatch implements NodeInterface
+func (f *FakeNodeRegistry) ApplyPatch(_ context.Context, name string, _ []byte) ([]byte, error) {
+	ret, err := f.invoke(Action{Method: "ApplyPatch", Name: name}, nil)
+	patch, _ := ret.([]byte)
+	return patch, err
+}
+
+// ApplyNode implements NodeInterface
+func (f *FakeNodeRegistry) ApplyNode(_ context.Context, name, _ string, _ []byte) (*api.Node, error) {
+	ret, err := f.invoke(Action{Method: "ApplyNode", Name: name}, nil)
+	node, _ := ret.(*api.Node)
+	return node, err
+}
+
+// MergeNode implements NodeInterface
+func (f *FakeNodeRegistry) MergeNode(_ context.Context, name string, _ []byte) (*api.Node, error) {
+	ret, err := f.invoke(Action{Method: "MergeNode", Name: name}, nil)
+	node, _ := ret.(*api.Node)
+	return node, err
+}
+
+// CordonNode implements NodeInterface
+func (f *FakeNodeRegistry) CordonNode(_ context.Context, name, _, _ string) error {
+	_, err := f.invoke(Action{Method: "CordonNode", Name: name}, nil)
+	return err
+}
+
+// UncordonNode implements NodeInterface
+func (f *FakeNodeRegistry) UncordonNode(_ context.Context, name string) error {
+	_, err := f.invoke(Action{Method: "UncordonNode", Name: name}, nil)
+	return err
+}
+
+// GetNodeSchedulability implements NodeInterface
+func (f *FakeNodeRegistry) GetNodeSchedulability(_ context.Context, name string) (*Schedulability, error) {
+	ret, err := f.invoke(Action{Method: "GetNodeSchedulability", Name: name}, nil)
+	s, _ := ret.(*Schedulability)
+	return s, err
+}
+
+// LabelNodes implements NodeInterface
+func (f *FakeNodeRegistry) LabelNodes(_ context.Context, _ labels.Selector, _ map[string]string, _ []string) ([]LabelResult, error) {
+	ret, err := f.invoke(Action{Method: "LabelNodes"}, nil)
+	results, _ := ret.([]LabelResult)
+	return results, err
+}
+
+// CountNodesByLabel implements NodeInterface
+func (f *FakeNodeRegistry) CountNodesByLabel(_ context.Context, _ string) (map[string]int, error) {
+	ret, err := f.invoke(Action{Method: "CountNodesByLabel"}, nil)
+	counts, _ := ret.(map[string]int)
+	return counts, err
+}
+
+// GetPoolSummary implements NodeInterface
+func (f *FakeNodeRegistry) GetPoolSummary(_ context.Context, _ string) (*PoolSummary, error) {
+	ret, err := f.invoke(Action{Method: "GetPoolSummary"}, nil)
+	summary, _ := ret.(*PoolSummary)
+	return summary, err
+}
+
+// GetStatusHistory implements NodeInterface
+func (f *FakeNodeRegistry) GetStatusHistory(_ context.Context, name string) ([]StatusTransition, error) {
+	ret, err := f.invoke(Action{Method: "GetStatusHistory", Name: name}, nil)
+	history, _ := ret.([]StatusTransition)
+	return history, err
+}
+
+// ExportNodes implements NodeInterface
+func (f *FakeNodeRegistry) ExportNodes(_ context.Context, _ io.Writer, _ string) error {
+	_, err := f.invoke(Action{Method: "ExportNodes"}, nil)
+	return err
+}
+
+// ImportNodes implements NodeInterface
+func (f *FakeNodeRegistry) ImportNodes(_ context.Context, _ io.Reader, _ bool) (*ImportResult, error) {
+	ret, err := f.invoke(Action{Method: "ImportNodes"}, nil)
+	result, _ := ret.(*ImportResult)
+	return result, err
+}
+
+// ExportNodesArchive implements NodeInterface
+func (f *FakeNodeRegistry) ExportNodesArchive(_ context.Context, _ io.Writer) error {
+	_, err := f.invoke(Action{Method: "ExportNodesArchive"}, nil)
+	return err
+}
+
+// ImportNodesArchive implements NodeInterface
+func (f *FakeNodeRegistry) ImportNodesArchive(_ context.Context, _ io.Reader, _ bool) (*ImportResult, error) {
+	ret, err := f.invoke(Action{Method: "ImportNodesArchive"}, nil)
+	result, _ := ret.(*ImportResult)
+	return result, err
+}
+
+// VerifyStore implements NodeInterface
+func (f *FakeNodeRegistry) VerifyStore(_ context.Context) (*VerifyReport, error) {
+	ret, err := f.invoke(Action{Method: "VerifyStore"}, nil)
+	report, _ := ret.(*VerifyReport)
+	return report, err
+}
+
+// ReplayEvents implements NodeInterface
+func (f *FakeNodeRegistry) ReplayEvents(_ context.Context, _ int64, _ func(event LoggedEvent) error) error {
+	_, err := f.invoke(Action{Method: "ReplayEvents"}, nil)
+	return err
+}
+
+var _ NodeInterface = &FakeNodeRegistry{}
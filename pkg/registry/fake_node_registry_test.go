@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gokube/pkg/api"
+	"gokube/pkg/watch"
+)
+
+func TestFakeNodeRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return a programmed conflict for a specific name", func(t *testing.T) {
+		fake := NewFakeNodeRegistry(
+			&api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-1"}},
+			&api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-2"}},
+		)
+		fake.AddReactor("UpdateNode", "test-node-1", func(action Action) (bool, any, error) {
+			return true, nil, ErrNodeConflict
+		})
+
+		err := fake.UpdateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-1"}, Spec: api.NodeSpec{Pool: "gpu"}})
+		assert.ErrorIs(t, err, ErrNodeConflict)
+		require.NoError(t, fake.UpdateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node-2"}, Spec: api.NodeSpec{Pool: "gpu"}}))
+
+		node, err := fake.GetNode(ctx, "test-node-1")
+		require.NoError(t, err)
+		assert.Empty(t, node.Spec.Pool)
+		node, err = fake.GetNode(ctx, "test-node-2")
+		require.NoError(t, err)
+		assert.Equal(t, "gpu", node.Spec.Pool)
+
+		var methods []string
+		for _, action := range fake.Actions() {
+			methods = append(methods, action.Method+" "+action.Name)
+		}
+		assert.Equal(t, []string{"UpdateNode test-node-1", "UpdateNode test-node-2", "GetNode test-node-1", "GetNode test-node-2"}, methods)
+	})
+
+	t.Run("should ask the next reactor when one doesn't handle a call", func(t *testing.T) {
+		fake := NewFakeNodeRegistry()
+		fake.AddReactor("*", "*", func(action Action) (bool, any, error) {
+			return false, nil, nil
+		})
+		fake.AddReactor("GetNode", "*", func(action Action) (bool, any, error) {
+			return true, &api.Node{ObjectMeta: api.ObjectMeta{Name: action.Name, Labels: map[string]string{"canned": "true"}}}, nil
+		})
+
+		node, err := fake.GetNode(ctx, "test-node")
+		require.NoError(t, err)
+		assert.Equal(t, "true", node.Labels["canned"])
+	})
+
+	t.Run("should serve unhandled calls from its nodes", func(t *testing.T) {
+		fake := NewFakeNodeRegistry()
+
+		w, err := fake.WatchNodes(ctx)
+		require.NoError(t, err)
+		defer w.Stop()
+
+		require.NoError(t, fake.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}))
+		assert.ErrorIs(t, fake.CreateNode(ctx, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}), ErrNodeAlreadyExists)
+
+		nodes, err := fake.ListNodes(ctx)
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		assert.Equal(t, "test-node", nodes[0].Name)
+
+		require.NoError(t, fake.DeleteNode(ctx, "test-node"))
+		_, err = fake.GetNode(ctx, "test-node")
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+
+		event := <-w.ResultChan()
+		assert.Equal(t, watch.Added, event.Type)
+		event = <-w.ResultChan()
+		assert.Equal(t, watch.Deleted, event.Type)
+		assert.Equal(t, uint64(2), fake.Revision())
+	})
+}
@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"gokube/pkg/api"
+	"gokube/pkg/labels"
+	"gokube/pkg/watch"
+)
+
+// NodeInterface is the API of a Node registry. NodeRegistry implements it on top of
+// a Storage; code that only uses Nodes should depend on NodeInterface instead, so
+// that its tests can use a FakeNodeRegistry. See NodeRegistry for what each method
+// does.
+type NodeInterface interface {
+	CreateNode(ctx context.Context, node *api.Node) error
+	GetNode(ctx context.Context, name string) (*api.Node, error)
+	NodeExists(ctx context.Context, name string) (bool, error)
+	UpdateNode(ctx context.Context, node *api.Node) error
+	UpdateNodeIfUnmodifiedSince(ctx context.Context, node *api.Node, since time.Time) error
+	UpdateNodeWithRetry(ctx context.Context, name string, mutate func(node *api.Node) error) error
+	DeleteNode(ctx context.Context, name string) error
+	DeleteNodesByName(ctx context.Context, names []string) []DeleteResult
+
+	ListNodes(ctx context.Context) ([]*api.Node, error)
+	StreamNodes(ctx context.Context, fn func(node *api.Node) error) error
+	ListNodesByPhase(ctx context.Context, phase api.NodePhase) ([]*api.Node, error)
+	ListNodesByVersionSkew(ctx context.Context, min, max string) ([]*api.Node, error)
+	FilterNodes(ctx context.Context, filter NodeFilter) ([]*api.Node, int, error)
+
+	WatchNodes(ctx context.Context) (watch.Interface, error)
+	WatchNodesWithInitialEvents(ctx context.Context) (watch.Interface, error)
+	WatchNode(ctx context.Context, name string) (watch.Interface, error)
+	Revision() uint64
+	WaitForChange(ctx context.Context, since uint64) (bool, error)
+
+	ApplyPatch(ctx context.Context, name string, applied []byte) ([]byte, error)
+	ApplyNode(ctx context.Context, name, fieldManager string, applied []byte) (*api.Node, error)
+	MergeNode(ctx context.Context, name string, body []byte) (*api.Node, error)
+
+	CordonNode(ctx context.Context, name, reason, by string) error
+	UncordonNode(ctx context.Context, name string) error
+	GetNodeSchedulability(ctx context.Context, name string) (*Schedulability, error)
+
+	LabelNodes(ctx context.Context, selector labels.Selector, add map[string]string, remove []string) ([]LabelResult, error)
+	CountNodesByLabel(ctx context.Context, key string) (map[string]int, error)
+	GetPoolSummary(ctx context.Context, pool string) (*PoolSummary, error)
+	GetStatusHistory(ctx context.Context, name string) ([]StatusTransition, error)
+
+	ExportNodes(ctx context.Context, w io.Writer, continueToken string) error
+	ImportNodes(ctx context.Context, rd io.Reader, overwrite bool) (*ImportResult, error)
+	ExportNodesArchive(ctx context.Context, w io.Writer) error
+	ImportNodesArchive(ctx context.Context, rd io.Reader, overwrite bool) (*ImportResult, error)
+	VerifyStore(ctx context.Context) (*VerifyReport, error)
+	ReplayEvents(ctx context.Context, fromRevision int64, fn func(event LoggedEvent) error) error
+}
+
+var _ NodeInterface = &NodeRegistry{}
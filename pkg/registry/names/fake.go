@@ -0,0 +1,31 @@
+package names
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeNameGenerator is a NameGenerator for tests that appends a counter instead of
+// random characters, so the names it generates are predictable. The suffix is as
+// long as SimpleNameGenerator's.
+type FakeNameGenerator struct {
+	mu    sync.Mutex
+	count int
+}
+
+// NewFakeNameGenerator returns a FakeNameGenerator whose first suffix is 00001
+func NewFakeNameGenerator() *FakeNameGenerator {
+	return &FakeNameGenerator{}
+}
+
+// GenerateName implements NameGenerator
+func (g *FakeNameGenerator) GenerateName(base string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.count++
+	if len(base) > MaxGeneratedNameLength {
+		base = base[:MaxGeneratedNameLength]
+	}
+	return fmt.Sprintf("%s%0*d", base, randomLength, g.count)
+}
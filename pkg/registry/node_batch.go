@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"context"
+)
+
+// DeleteStatus is the outcome of deleting one Node in a batch
+type DeleteStatus string
+
+const (
+	DeleteStatusDeleted  DeleteStatus = "Deleted"
+	DeleteStatusNotFound DeleteStatus = "NotFound"
+	DeleteStatusFailed   DeleteStatus = "Failed"
+)
+
+// DeleteResult reports what DeleteNodesByName did with one name
+type DeleteResult struct {
+	Name   string       `json:"name"`
+	Status DeleteStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// DeleteNodesByName deletes the Nodes with the given names, one at a time, and
+// reports the outcome for each name in the order given. A failure on one name
+// doesn't stop the others.
+func (r *NodeRegistry) DeleteNodesByName(ctx context.Context, names []string) []DeleteResult {
+	results := make([]DeleteResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, r.deleteNodeForBatch(ctx, name))
+	}
+	return results
+}
+
+func (r *NodeRegistry) deleteNodeForBatch(ctx context.Context, name string) DeleteResult {
+	result := DeleteResult{Name: name, Status: DeleteStatusFailed}
+
+	// Deleting and checking existence in one request leaves no window for the
+	// Node to be created or deleted in between
+	existed, err := r.deleteNode(ctx, name)
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+	case !existed:
+		result.Status = DeleteStatusNotFound
+	default:
+		result.Status = DeleteStatusDeleted
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"gokube/pkg/api"
+)
+
+// CordonNode marks the Node name unschedulable and records why and by whom, so that
+// whoever later considers uncordoning it knows what it was cordoned for. Cordoning
+// an already cordoned node replaces the recorded reason.
+func (r *NodeRegistry) CordonNode(ctx context.Context, name, reason, by string) error {
+	return r.UpdateNodeWithRetry(ctx, name, func(node *api.Node) error {
+		node.Spec.Unschedulable = true
+		node.Spec.CordonReason = reason
+		node.Spec.CordonedBy = by
+		node.Spec.CordonedAt = r.clock.Now()
+		return nil
+	})
+}
+
+// UncordonNode marks the Node name schedulable again and clears the cordon record
+func (r *NodeRegistry) UncordonNode(ctx context.Context, name string) error {
+	return r.UpdateNodeWithRetry(ctx, name, func(node *api.Node) error {
+		node.Spec.Unschedulable = false
+		node.Spec.CordonReason = ""
+		node.Spec.CordonedBy = ""
+		node.Spec.CordonedAt = time.Time{}
+		return nil
+	})
+}
+
+// Schedulability is the cordon state of a Node
+type Schedulability struct {
+	Unschedulable bool       `json:"unschedulable"`
+	Reason        string     `json:"reason,omitempty"`
+	By            string     `json:"by,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+}
+
+// GetNodeSchedulability reports whether the Node name is cordoned and, if it was
+// cordoned with CordonNode, why, by whom and since when
+func (r *NodeRegistry) GetNodeSchedulability(ctx context.Context, name string) (*Schedulability, error) {
+	node, err := r.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schedulability{
+		Unschedulable: node.Spec.Unschedulable,
+		Reason:        node.Spec.CordonReason,
+		By:            node.Spec.CordonedBy,
+	}
+	if !node.Spec.CordonedAt.IsZero() {
+		since := node.Spec.CordonedAt
+		s.Since = &since
+	}
+	return s, nil
+}
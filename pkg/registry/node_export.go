@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gokube/pkg/api"
+)
+
+// ImportResult reports what ImportNodes did with the nodes it read
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// ExportNodes writes all Nodes to w as newline-delimited JSON, ordered by name.
+// The nodes are read from a single storage snapshot. If continueToken is set,
+// the export resumes after the node with that name, so a client whose export
+// was interrupted can pass the name of the last node it received.
+func (r *NodeRegistry) ExportNodes(ctx context.Context, w io.Writer, continueToken string) error {
+	nodes, err := r.sortedNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, node := range nodes {
+		if node.Name <= continueToken {
+			continue
+		}
+		if err := encoder.Encode(node); err != nil {
+			return fmt.Errorf("failed to export node %s: %w", node.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ImportNodes reads newline-delimited JSON Nodes from rd and creates them. Nodes
+// that already exist are overwritten when overwrite is set and skipped otherwise.
+func (r *NodeRegistry) ImportNodes(ctx context.Context, rd io.Reader, overwrite bool) (*ImportResult, error) {
+	result := &ImportResult{}
+	decoder := json.NewDecoder(rd)
+	for {
+		node := &api.Node{}
+		if err := decoder.Decode(node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return result, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+		}
+
+		if err := r.importNode(ctx, node, overwrite, result); err != nil {
+			return result, err
+		}
+	}
+}
+
+// ExportNodesArchive writes all Nodes to w as a gzip-compressed tar archive with
+// one JSON file per node, named <node name>.json, so single nodes can be inspected
+// or restored by hand.
+func (r *NodeRegistry) ExportNodesArchive(ctx context.Context, w io.Writer) error {
+	nodes, err := r.sortedNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, node := range nodes {
+		data, err := json.MarshalIndent(node, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to export node %s: %w", node.Name, err)
+		}
+		header := &tar.Header{
+			Name:    node.Name + ".json",
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: node.LastModified,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to export node %s: %w", node.Name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to export node %s: %w", node.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish export archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// ImportNodesArchive restores Nodes from an archive written by ExportNodesArchive.
+// Entries that are not regular .json files are ignored, so an archive that was
+// unpacked and repacked by hand can still be imported.
+func (r *NodeRegistry) ImportNodesArchive(ctx context.Context, rd io.Reader, overwrite bool) (*ImportResult, error) {
+	result := &ImportResult{}
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".json") {
+			continue
+		}
+
+		node := &api.Node{}
+		if err := json.NewDecoder(tr).Decode(node); err != nil {
+			return result, fmt.Errorf("%w: %s: %v", ErrNodeInvalid, header.Name, err)
+		}
+		if err := r.importNode(ctx, node, overwrite, result); err != nil {
+			return result, err
+		}
+	}
+}
+
+// sortedNodes returns all Nodes ordered by name
+func (r *NodeRegistry) sortedNodes(ctx context.Context) ([]*api.Node, error) {
+	nodes, err := r.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
+
+// importNode creates node, or overwrites or skips it if it already exists, and
+// records the outcome in result
+func (r *NodeRegistry) importNode(ctx context.Context, node *api.Node, overwrite bool, result *ImportResult) error {
+	err := r.CreateNode(ctx, node)
+	switch {
+	case err == nil:
+		result.Created++
+	case errors.Is(err, ErrNodeAlreadyExists) && overwrite:
+		if err := r.UpdateNode(ctx, node); err != nil {
+			return err
+		}
+		result.Updated++
+	case errors.Is(err, ErrNodeAlreadyExists):
+		result.Skipped++
+	default:
+		return err
+	}
+	return nil
+}
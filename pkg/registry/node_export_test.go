@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/storage"
+)
+
+func TestNodeRegistry_ExportImportNodes(t *testing.T) {
+	t.Run("should round-trip nodes into a fresh store", func(t *testing.T) {
+		var exported bytes.Buffer
+
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			for i := 0; i < 100; i++ {
+				createTestNodeInRegistry(t, nodeRegistry, fmt.Sprintf("test-node-%03d", i), fmt.Sprintf("%d", i))
+			}
+
+			err := nodeRegistry.ExportNodes(context.Background(), &exported, "")
+			require.NoError(t, err)
+		})
+
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			ctx := context.Background()
+
+			result, err := nodeRegistry.ImportNodes(ctx, bytes.NewReader(exported.Bytes()), false)
+			require.NoError(t, err)
+			assert.Equal(t, &ImportResult{Created: 100}, result)
+
+			nodes, err := nodeRegistry.ListNodes(ctx)
+			require.NoError(t, err)
+			require.Len(t, nodes, 100)
+			for i, node := range nodes {
+				assert.Equal(t, fmt.Sprintf("test-node-%03d", i), node.Name)
+				assert.Equal(t, fmt.Sprintf("%d", i), node.UID)
+			}
+		})
+	})
+
+	t.Run("should resume export after the continue token", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-a", "1")
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-b", "2")
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-c", "3")
+
+			var exported bytes.Buffer
+			err := nodeRegistry.ExportNodes(context.Background(), &exported, "test-node-a")
+			require.NoError(t, err)
+
+			var names []string
+			scanner := bufio.NewScanner(&exported)
+			for scanner.Scan() {
+				node := &api.Node{}
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), node))
+				names = append(names, node.Name)
+			}
+			assert.Equal(t, []string{"test-node-b", "test-node-c"}, names)
+		})
+	})
+
+	t.Run("should skip or overwrite existing nodes", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			ctx := context.Background()
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-1", "old")
+
+			body, err := json.Marshal(createTestNode("test-node-1", "new"))
+			require.NoError(t, err)
+
+			result, err := nodeRegistry.ImportNodes(ctx, bytes.NewReader(body), false)
+			require.NoError(t, err)
+			assert.Equal(t, &ImportResult{Skipped: 1}, result)
+
+			node, err := nodeRegistry.GetNode(ctx, "test-node-1")
+			require.NoError(t, err)
+			assert.Equal(t, "old", node.UID)
+
+			result, err = nodeRegistry.ImportNodes(ctx, bytes.NewReader(body), true)
+			require.NoError(t, err)
+			assert.Equal(t, &ImportResult{Updated: 1}, result)
+
+			node, err = nodeRegistry.GetNode(ctx, "test-node-1")
+			require.NoError(t, err)
+			assert.Equal(t, "new", node.UID)
+		})
+	})
+
+	t.Run("should restore a tar.gz archive into an empty store", func(t *testing.T) {
+		var archive bytes.Buffer
+		names := []string{"test-node-a", "test-node-b", "test-node-c"}
+
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			for i, name := range names {
+				createTestNodeInRegistry(t, nodeRegistry, name, fmt.Sprintf("%d", i))
+			}
+
+			err := nodeRegistry.ExportNodesArchive(context.Background(), &archive)
+			require.NoError(t, err)
+		})
+
+		gz, err := gzip.NewReader(bytes.NewReader(archive.Bytes()))
+		require.NoError(t, err)
+		var files []string
+		tr := tar.NewReader(gz)
+		for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+			files = append(files, header.Name)
+		}
+		assert.Equal(t, []string{"test-node-a.json", "test-node-b.json", "test-node-c.json"}, files)
+
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			ctx := context.Background()
+
+			result, err := nodeRegistry.ImportNodesArchive(ctx, bytes.NewReader(archive.Bytes()), false)
+			require.NoError(t, err)
+			assert.Equal(t, &ImportResult{Created: 3}, result)
+
+			for i, name := range names {
+				node, err := nodeRegistry.GetNode(ctx, name)
+				require.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("%d", i), node.UID)
+			}
+		})
+	})
+
+	t.Run("should reject malformed input", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+
+			_, err := nodeRegistry.ImportNodes(context.Background(), bytes.NewReader([]byte("{not json")), false)
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+
+			_, err = nodeRegistry.ImportNodesArchive(context.Background(), bytes.NewReader([]byte("not gzip")), false)
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+	})
+}
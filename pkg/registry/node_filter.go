@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gokube/pkg/api"
+	"gokube/pkg/labels"
+)
+
+// NodeFilter selects which Nodes a list returns. The zero value matches every Node.
+type NodeFilter struct {
+	Phase         api.NodePhase
+	Pool          string
+	LabelSelector labels.Selector
+	// FieldSelector matches against the fields returned by nodeFields; parse it with
+	// ParseFieldSelector
+	FieldSelector labels.Selector
+	// Schedulable limits the list to Nodes that new work may be placed on
+	Schedulable bool
+}
+
+// Empty reports whether the filter matches every Node
+func (f NodeFilter) Empty() bool {
+	return f.Phase == "" && f.Pool == "" && !f.Schedulable && f.LabelSelector.Empty() && f.FieldSelector.Empty()
+}
+
+// Matches reports whether node satisfies every condition of the filter. Conditions
+// are checked cheapest first and the first one that fails decides.
+func (f NodeFilter) Matches(node *api.Node) bool {
+	if f.Phase != "" && node.Phase() != f.Phase {
+		return false
+	}
+	if f.Pool != "" && node.Spec.Pool != f.Pool {
+		return false
+	}
+	if f.Schedulable && !node.Schedulable() {
+		return false
+	}
+	if !f.LabelSelector.Matches(node.Labels) {
+		return false
+	}
+	return f.FieldSelector.Empty() || f.FieldSelector.Matches(nodeFields(node))
+}
+
+// nodeFields returns the Node fields a field selector can match on
+func nodeFields(node *api.Node) labels.Set {
+	return labels.Set{
+		"metadata.name":      node.Name,
+		"spec.pool":          node.Spec.Pool,
+		"spec.unschedulable": strconv.FormatBool(node.Spec.Unschedulable),
+		"status":             string(node.Status),
+	}
+}
+
+// ParseFieldSelector parses a field selector such as "spec.pool=gpu,status!=Ready".
+// Only the fields returned by nodeFields can be selected on, and only with =, ==
+// and !=, since every Node has all of them.
+func ParseFieldSelector(selector string) (labels.Selector, error) {
+	s, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+
+	fields := nodeFields(&api.Node{})
+	for _, r := range s {
+		if _, ok := fields[r.Key]; !ok {
+			return nil, fmt.Errorf("%w: field selector on unsupported field %q", ErrNodeInvalid, r.Key)
+		}
+		if r.Operator != labels.Equals && r.Operator != labels.NotEquals {
+			return nil, fmt.Errorf("%w: field selector %q must compare the field to a value", ErrNodeInvalid, r)
+		}
+	}
+	return s, nil
+}
+
+// FilterNodes retrieves the Nodes matching filter, along with the total number of
+// Nodes in the registry before filtering
+func (r *NodeRegistry) FilterNodes(ctx context.Context, filter NodeFilter) ([]*api.Node, int, error) {
+	nodes, err := r.ListNodes(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.Empty() {
+		return nodes, len(nodes), nil
+	}
+
+	filtered := make([]*api.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if filter.Matches(node) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered, len(nodes), nil
+}
+
+// PageNodes returns at most limit of nodes that sort after continueToken, along with
+// the token to pass to get the next page or "" if there are no more. nodes must be
+// sorted by name, as ListNodes returns them.
+func PageNodes(nodes []*api.Node, continueToken string, limit int) ([]*api.Node, string) {
+	start := sort.Search(len(nodes), func(i int) bool {
+		return nodes[i].Name > continueToken
+	})
+	nodes = nodes[start:]
+	if limit <= 0 || len(nodes) <= limit {
+		return nodes, ""
+	}
+	return nodes[:limit], nodes[limit-1].Name
+}
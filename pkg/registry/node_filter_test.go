@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gokube/pkg/api"
+	"gokube/pkg/labels"
+)
+
+func TestNodeFilter_Matches(t *testing.T) {
+	node := createTestNode("gpu-1", "1")
+	node.Labels = map[string]string{"zone": "a"}
+	node.Spec.Pool = "gpu"
+
+	fieldSelector := func(s string) labels.Selector {
+		selector, err := ParseFieldSelector(s)
+		require.NoError(t, err)
+		return selector
+	}
+	labelSelector := func(s string) labels.Selector {
+		selector, err := labels.Parse(s)
+		require.NoError(t, err)
+		return selector
+	}
+
+	t.Run("should match when both label and field selectors hold", func(t *testing.T) {
+		filter := NodeFilter{LabelSelector: labelSelector("zone=a"), FieldSelector: fieldSelector("spec.pool=gpu")}
+		assert.True(t, filter.Matches(node))
+	})
+
+	t.Run("should not match when only one of the selectors holds", func(t *testing.T) {
+		assert.False(t, NodeFilter{LabelSelector: labelSelector("zone=b"), FieldSelector: fieldSelector("spec.pool=gpu")}.Matches(node))
+		assert.False(t, NodeFilter{LabelSelector: labelSelector("zone=a"), FieldSelector: fieldSelector("metadata.name!=gpu-1")}.Matches(node))
+	})
+}
+
+func TestParseFieldSelector(t *testing.T) {
+	t.Run("should parse selectors on supported fields", func(t *testing.T) {
+		selector, err := ParseFieldSelector("metadata.name=gpu-1,spec.unschedulable!=true")
+		require.NoError(t, err)
+		assert.Len(t, selector, 2)
+	})
+
+	t.Run("should reject unsupported fields and operators", func(t *testing.T) {
+		for _, s := range []string{"spec.zone=a", "spec.pool", "!status"} {
+			_, err := ParseFieldSelector(s)
+			assert.ErrorIs(t, err, ErrNodeInvalid, s)
+		}
+	})
+}
+
+func TestPageNodes(t *testing.T) {
+	nodes := []*api.Node{
+		createTestNode("node-a", "1"),
+		createTestNode("node-b", "2"),
+		createTestNode("node-c", "3"),
+	}
+	names := func(nodes []*api.Node) []string {
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+
+	page, next := PageNodes(nodes, "", 2)
+	assert.Equal(t, []string{"node-a", "node-b"}, names(page))
+	assert.Equal(t, "node-b", next)
+
+	page, next = PageNodes(nodes, next, 2)
+	assert.Equal(t, []string{"node-c"}, names(page))
+	assert.Empty(t, next)
+
+	page, next = PageNodes(nodes, "", 0)
+	assert.Len(t, page, 3)
+	assert.Empty(t, next)
+}
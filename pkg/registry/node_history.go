@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gokube/pkg/api"
+)
+
+// defaultStatusHistorySize is how many status transitions are kept per Node
+const defaultStatusHistorySize = 10
+
+// StatusTransition records a Node changing from one status to another. The first
+// transition of a Node has an empty From.
+type StatusTransition struct {
+	From api.NodeStatus `json:"from,omitempty"`
+	To   api.NodeStatus `json:"to"`
+	Time time.Time      `json:"time"`
+}
+
+// WithStatusHistorySize sets how many status transitions are kept per Node; the
+// oldest ones are dropped first. A size <= 0 disables the history.
+func WithStatusHistorySize(size int) Option {
+	return func(r *NodeRegistry) {
+		r.history.size = size
+	}
+}
+
+// GetStatusHistory returns the most recent status transitions of the Node name,
+// oldest first. The history is kept in memory by this registry, so it only covers
+// changes made through it since it was created.
+func (r *NodeRegistry) GetStatusHistory(ctx context.Context, name string) ([]StatusTransition, error) {
+	node, err := r.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.history.get(node.Name), nil
+}
+
+// statusHistory keeps a bounded ring of status transitions for every Node
+type statusHistory struct {
+	mu    sync.Mutex
+	size  int
+	rings map[string]*transitionRing
+}
+
+// transitionRing holds up to len(entries) transitions, next is where the next one goes
+type transitionRing struct {
+	entries []StatusTransition
+	next    int
+	full    bool
+}
+
+// record adds a transition if status differs from the last one recorded for name
+func (h *statusHistory) record(name string, status api.NodeStatus, at time.Time) {
+	if h.size <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rings == nil {
+		h.rings = make(map[string]*transitionRing)
+	}
+	ring, ok := h.rings[name]
+	if !ok {
+		ring = &transitionRing{entries: make([]StatusTransition, h.size)}
+		h.rings[name] = ring
+	}
+
+	var from api.NodeStatus
+	if ring.next > 0 || ring.full {
+		from = ring.entries[(ring.next+h.size-1)%h.size].To
+		if from == status {
+			return
+		}
+	}
+
+	ring.entries[ring.next] = StatusTransition{From: from, To: status, Time: at}
+	ring.next = (ring.next + 1) % h.size
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+// get returns the transitions recorded for name, oldest first
+func (h *statusHistory) get(name string) []StatusTransition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	transitions := []StatusTransition{}
+	ring, ok := h.rings[name]
+	if !ok {
+		return transitions
+	}
+	if ring.full {
+		transitions = append(transitions, ring.entries[ring.next:]...)
+	}
+	return append(transitions, ring.entries[:ring.next]...)
+}
+
+// forget drops the history of a deleted Node
+func (h *statusHistory) forget(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.rings, name)
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"context"
+	"errors"
+
+	"gokube/pkg/api"
+	"gokube/pkg/labels"
+)
+
+// errLabelsUnchanged aborts the update of a Node that already has the labels asked
+// for
+var errLabelsUnchanged = errors.New("labels unchanged")
+
+// LabelResult reports what LabelNodes did to one Node
+type LabelResult struct {
+	Name    string `json:"name"`
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// LabelNodes adds and removes labels on every Node matching selector. Each Node is
+// updated on its own, so a failure on one doesn't stop the others; the results say
+// what happened to each matching Node. The delta is applied to the latest version
+// of each Node, so label changes made since the Nodes were listed are kept.
+func (r *NodeRegistry) LabelNodes(ctx context.Context, selector labels.Selector, add map[string]string, remove []string) ([]LabelResult, error) {
+	nodes, _, err := r.FilterNodes(ctx, NodeFilter{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]LabelResult, 0, len(nodes))
+	for _, node := range nodes {
+		result := LabelResult{Name: node.Name}
+		err := r.UpdateNodeWithRetry(ctx, node.Name, func(node *api.Node) error {
+			if !applyLabelDelta(node, add, remove) {
+				return errLabelsUnchanged
+			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, errLabelsUnchanged):
+		case err != nil:
+			result.Error = err.Error()
+		default:
+			result.Changed = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// CountNodesByLabel counts the Nodes that have the label key, by the label's value.
+// There is no label index to answer from, so the Nodes are streamed from storage
+// rather than listed, and only the counts are kept in memory.
+func (r *NodeRegistry) CountNodesByLabel(ctx context.Context, key string) (map[string]int, error) {
+	counts := map[string]int{}
+	err := r.StreamNodes(ctx, func(node *api.Node) error {
+		if value, ok := node.Labels[key]; ok {
+			counts[value]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// applyLabelDelta adds and removes labels on node, reporting whether anything changed
+func applyLabelDelta(node *api.Node, add map[string]string, remove []string) bool {
+	changed := false
+	for _, key := range remove {
+		if _, ok := node.Labels[key]; ok {
+			delete(node.Labels, key)
+			changed = true
+		}
+	}
+	for key, value := range add {
+		if current, ok := node.Labels[key]; ok && current == value {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		node.Labels[key] = value
+		changed = true
+	}
+	return changed
+}
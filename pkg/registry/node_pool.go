@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"context"
+
+	"gokube/pkg/api"
+)
+
+// PoolSummary aggregates the Nodes of one node pool
+type PoolSummary struct {
+	Pool  string `json:"pool"`
+	Nodes int    `json:"nodes"`
+	Ready int    `json:"ready"`
+	// Schedulable counts the Ready Nodes that are not cordoned
+	Schedulable int `json:"schedulable"`
+}
+
+// GetPoolSummary counts the Nodes in pool, how many of them are Ready and how many
+// are schedulable
+func (r *NodeRegistry) GetPoolSummary(ctx context.Context, pool string) (*PoolSummary, error) {
+	if pool == "" {
+		return nil, ErrNodeInvalid
+	}
+
+	nodes, _, err := r.FilterNodes(ctx, NodeFilter{Pool: pool})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PoolSummary{Pool: pool, Nodes: len(nodes)}
+	for _, node := range nodes {
+		if node.Phase() == api.NodePhaseReady {
+			summary.Ready++
+		}
+		if node.Schedulable() {
+			summary.Schedulable++
+		}
+	}
+	return summary, nil
+}
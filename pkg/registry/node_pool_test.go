@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/storage"
+)
+
+func TestNodeRegistry_GetPoolSummary(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		for name, pool := range map[string]string{"gpu-1": "gpu", "gpu-2": "gpu", "gpu-3": "gpu", "cpu-1": "cpu"} {
+			node := createTestNode(name, name)
+			node.Spec.Pool = pool
+			if name != "gpu-2" {
+				node.Status = api.NodeReady
+			}
+			// A cordoned node is Ready but not schedulable
+			node.Spec.Unschedulable = name == "gpu-3"
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+		}
+
+		summary, err := nodeRegistry.GetPoolSummary(ctx, "gpu")
+		require.NoError(t, err)
+		assert.Equal(t, &PoolSummary{Pool: "gpu", Nodes: 3, Ready: 2, Schedulable: 1}, summary)
+
+		nodes, _, err := nodeRegistry.FilterNodes(ctx, NodeFilter{Pool: "gpu", Schedulable: true})
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		assert.Equal(t, "gpu-1", nodes[0].Name)
+
+		summary, err = nodeRegistry.GetPoolSummary(ctx, "missing")
+		require.NoError(t, err)
+		assert.Equal(t, &PoolSummary{Pool: "missing"}, summary)
+	})
+}
@@ -5,30 +5,175 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/registry/names"
+	"gokube/pkg/registry/uid"
+	"gokube/pkg/runtime"
 	"gokube/pkg/storage"
+	"gokube/pkg/watch"
 )
 
 const (
+	// nodePrefix is the default storage key prefix for Node objects
 	nodePrefix = "/registry/nodes/"
+	// defaultUpdateRetries is how often UpdateNodeWithRetry retries on a conflict
+	defaultUpdateRetries = 5
 )
 
 var (
-	ErrNodeNotFound      = errors.New("node not found")
-	ErrNodeAlreadyExists = errors.New("node already exists")
-	ErrListNodesFailed   = errors.New("failed to list nodes")
-	ErrNodeInvalid       = errors.New("invalid node")
+	ErrNodeNotFound       = errors.New("node not found")
+	ErrNodeAlreadyExists  = errors.New("node already exists")
+	ErrListNodesFailed    = errors.New("failed to list nodes")
+	ErrNodeInvalid        = errors.New("invalid node")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrNodeConflict       = errors.New("node was modified concurrently")
 )
 
 // NodeRegistry provides CRUD operations for Node objects
 type NodeRegistry struct {
-	storage storage.Storage
+	storage     storage.Storage
+	broadcaster *watch.Broadcaster
+	clock       clock.Clock
+	prefix      string
+	// mutatingAdmission runs before validation, admission after it
+	mutatingAdmission []Admission
+	admission         []Admission
+	validators        []ValidationFunc
+	// observeValidation, if set, is told the field of every validation failure
+	observeValidation func(field string)
+
+	// defaultLabels are set on created Nodes that don't have them
+	defaultLabels map[string]string
+	// cordonedRoles are the roles whose Nodes are created cordoned
+	cordonedRoles  []string
+	eventLog       EventLog
+	normalizeNames bool
+	nameGenerator  names.NameGenerator
+	uidGenerator   uid.Generator
+	history        statusHistory
+	// revision counts the mutations notified to watchers, see Revision
+	revision atomic.Uint64
+
+	watchBufferSize int
+	// coalesceWindow collapses rapid Modified events per watcher when non-zero
+	coalesceWindow time.Duration
+	updateRetries  int
+}
+
+// Option configures optional NodeRegistry behaviour
+type Option func(*NodeRegistry)
+
+// WithWatchBufferSize sets how many events each watcher may have pending before
+// it is disconnected
+func WithWatchBufferSize(size int) Option {
+	return func(r *NodeRegistry) {
+		r.watchBufferSize = size
+	}
+}
+
+// WithWatchCoalescing makes every watcher receive only the latest of the Modified
+// events for a Node that arrive within window. Other events are never dropped.
+func WithWatchCoalescing(window time.Duration) Option {
+	return func(r *NodeRegistry) {
+		r.coalesceWindow = window
+	}
+}
+
+// WithClock sets the clock used for timestamps, defaulting to the real clock
+func WithClock(c clock.Clock) Option {
+	return func(r *NodeRegistry) {
+		r.clock = c
+	}
+}
+
+// WithUpdateRetries sets how many times UpdateNodeWithRetry retries after a conflict
+func WithUpdateRetries(retries int) Option {
+	return func(r *NodeRegistry) {
+		r.updateRetries = retries
+	}
+}
+
+// WithDefaultLabels sets labels that every created Node gets unless it already has
+// a value for the key
+func WithDefaultLabels(labels map[string]string) Option {
+	return func(r *NodeRegistry) {
+		r.defaultLabels = labels
+	}
+}
+
+// WithCordonedRoles makes Nodes that are created with one of roles, per their
+// api.LabelNodeRolePrefix labels, start out cordoned, e.g. api.NodeRoleControlPlane
+// so that the control plane isn't given workloads unless it is uncordoned
+func WithCordonedRoles(roles ...string) Option {
+	return func(r *NodeRegistry) {
+		r.cordonedRoles = roles
+	}
+}
+
+// WithNameGenerator sets how names are generated for Nodes created with only a
+// GenerateName, e.g. names.NewFakeNameGenerator for predictable names in tests.
+// The default appends random characters.
+func WithNameGenerator(g names.NameGenerator) Option {
+	return func(r *NodeRegistry) {
+		r.nameGenerator = g
+	}
 }
 
-// NewNodeRegistry creates a new NodeRegistry
-func NewNodeRegistry(storage storage.Storage) *NodeRegistry {
-	return &NodeRegistry{storage: storage}
+// WithUIDGenerator sets how UIDs are generated for Nodes created without one, e.g.
+// uid.NewFakeGenerator for predictable UIDs in tests. The default generates random
+// UUIDs.
+func WithUIDGenerator(g uid.Generator) Option {
+	return func(r *NodeRegistry) {
+		r.uidGenerator = g
+	}
+}
+
+// WithNameNormalization makes the registry lowercase Node names and strip trailing
+// dots, so that hostnames such as "Worker-1." and "worker-1" name the same Node
+func WithNameNormalization() Option {
+	return func(r *NodeRegistry) {
+		r.normalizeNames = true
+	}
+}
+
+// WithKeyPrefix sets the storage key prefix for Node objects, so that several
+// independent registries can share one storage backend
+func WithKeyPrefix(prefix string) Option {
+	return func(r *NodeRegistry) {
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		r.prefix = prefix
+	}
+}
+
+// NewNodeRegistry creates a new NodeRegistry. It panics if storage is nil, since
+// that is a misconfiguration that would otherwise only surface on the first
+// request.
+func NewNodeRegistry(storage storage.Storage, opts ...Option) *NodeRegistry {
+	if storage == nil {
+		panic("registry: NewNodeRegistry called with nil storage")
+	}
+	r := &NodeRegistry{
+		storage:       storage,
+		clock:         clock.RealClock{},
+		prefix:        nodePrefix,
+		updateRetries: defaultUpdateRetries,
+		nameGenerator: randomNameGenerator{},
+		uidGenerator:  uid.RandomGenerator,
+	}
+	r.history.size = defaultStatusHistorySize
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.broadcaster = watch.NewBroadcaster(r.watchBufferSize)
+
+	return r
 }
 
 // generateKey generates the storage key for a given node name
@@ -36,37 +181,38 @@ func generateKey(prefix, name string) string {
 	return path.Join(prefix, name)
 }
 
-// CreateNode stores a new Node
+// CreateNode stores a new Node. A Node without a name gets one generated from its
+// GenerateName prefix.
 func (r *NodeRegistry) CreateNode(ctx context.Context, node *api.Node) error {
-	if node == nil || node.Name == "" {
-		return ErrNodeInvalid
-	}
-	if err := node.Validate(); err != nil {
+	if node == nil || (node.Name == "" && node.GenerateName == "") {
 		return ErrNodeInvalid
 	}
-
-	// Check if node already exists
-	key := generateKey(nodePrefix, node.Name)
-	existingNode := &api.Node{}
-	if err := r.storage.Get(ctx, key, existingNode); err == nil {
-		return ErrNodeAlreadyExists
+	r.defaultNodeOnCreate(node)
+	if err := r.admitAndValidate(ctx, OperationCreate, node); err != nil {
+		return err
 	}
 
-
+	key := generateKey(r.prefix, node.Name)
 	if err := r.storage.Create(ctx, key, node); err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return ErrNodeAlreadyExists
+		}
 		return ErrInternal
 	}
 
+	r.history.record(node.Name, node.Status, node.LastModified)
+	r.notify(ctx, watch.Added, node)
 	return nil
 }
 
 // GetNode retrieves a Node by name
 func (r *NodeRegistry) GetNode(ctx context.Context, name string) (*api.Node, error) {
+	name = r.normalizeName(name)
 	if name == "" {
 		return nil, ErrNodeInvalid
 	}
 
-	key := generateKey(nodePrefix, name)
+	key := generateKey(r.prefix, name)
 	node := &api.Node{}
 	err := r.storage.Get(ctx, key, node)
 	if err != nil {
@@ -79,57 +225,284 @@ func (r *NodeRegistry) GetNode(ctx context.Context, name string) (*api.Node, err
 	return node, nil
 }
 
+// getNode is GetNode that also returns the storage revision of the Node read
+func (r *NodeRegistry) getNode(ctx context.Context, name string) (*api.Node, int64, error) {
+	name = r.normalizeName(name)
+	if name == "" {
+		return nil, 0, ErrNodeInvalid
+	}
+
+	node := &api.Node{}
+	revision, err := r.storage.GetWithRevision(ctx, generateKey(r.prefix, name), node)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, 0, ErrNodeNotFound
+	}
+	if err != nil {
+		return nil, 0, ErrInternal
+	}
+	return node, revision, nil
+}
+
+// NodeExists reports whether a Node named name exists without fetching it
+func (r *NodeRegistry) NodeExists(ctx context.Context, name string) (bool, error) {
+	name = r.normalizeName(name)
+	if name == "" {
+		return false, ErrNodeInvalid
+	}
+
+	exists, err := r.storage.Exists(ctx, generateKey(r.prefix, name))
+	if err != nil {
+		return false, ErrInternal
+	}
+	return exists, nil
+}
+
 // UpdateNode updates an existing Node
 func (r *NodeRegistry) UpdateNode(ctx context.Context, node *api.Node) error {
+	return r.replaceNode(ctx, node, nil)
+}
+
+// UpdateNodeIfUnmodifiedSince updates an existing Node only if it hasn't been
+// modified after since, failing with ErrPreconditionFailed otherwise. since has
+// the one second resolution of HTTP dates, so modifications are compared by second.
+func (r *NodeRegistry) UpdateNodeIfUnmodifiedSince(ctx context.Context, node *api.Node, since time.Time) error {
+	return r.replaceNode(ctx, node, func(existing *api.Node) error {
+		if existing.LastModified.Truncate(time.Second).After(since) {
+			return fmt.Errorf("%w: node %s was modified at %s", ErrPreconditionFailed, existing.Name, existing.LastModified.UTC().Format(time.RFC3339))
+		}
+		return nil
+	})
+}
+
+// UpdateNodeWithRetry reads the Node name, applies mutate to it and writes it back.
+// If the node was modified by someone else in between, the whole read-modify-write
+// is retried, up to the configured number of retries, after which ErrNodeConflict
+// is returned. An error from mutate aborts the update and is returned unchanged.
+func (r *NodeRegistry) UpdateNodeWithRetry(ctx context.Context, name string, mutate func(node *api.Node) error) error {
+	for attempt := 0; ; attempt++ {
+		node, revision, err := r.getNode(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(node); err != nil {
+			return err
+		}
+		node.Name = name
+
+		err = r.updateNode(ctx, node, revision, nil)
+		if !errors.Is(err, ErrNodeConflict) || attempt >= r.updateRetries {
+			return err
+		}
+	}
+}
+
+// replaceNode is updateNode for callers that didn't read the Node themselves. If
+// another write lands between the read of the stored version and the write, the
+// precondition is checked again against the newer version and the write retried.
+func (r *NodeRegistry) replaceNode(ctx context.Context, node *api.Node, precondition func(existing *api.Node) error) error {
+	for attempt := 0; ; attempt++ {
+		err := r.updateNode(ctx, node, 0, precondition)
+		if !errors.Is(err, ErrNodeConflict) || attempt >= r.updateRetries {
+			return err
+		}
+	}
+}
+
+// updateNode updates an existing Node after checking precondition, if set, against
+// the stored version. The Node is only written if the stored version is unchanged
+// since it was read, and since revision if that is set, failing with
+// ErrNodeConflict otherwise.
+func (r *NodeRegistry) updateNode(ctx context.Context, node *api.Node, revision int64, precondition func(existing *api.Node) error) error {
 	// Validate node
 	if node == nil || node.Name == "" {
 		return ErrNodeInvalid
 	}
-	if err := node.Validate(); err != nil {
-		return ErrNodeInvalid
+	node.Name = r.normalizeName(node.Name)
+	if err := r.admitAndValidate(ctx, OperationUpdate, node); err != nil {
+		return err
 	}
 
 	// Check if node exists
-	key := generateKey(nodePrefix, node.Name)
+	key := generateKey(r.prefix, node.Name)
 	existingNode := &api.Node{}
-	err := r.storage.Get(ctx, key, existingNode)
+	existingRevision, err := r.storage.GetWithRevision(ctx, key, existingNode)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			return ErrNodeNotFound
 		}
 		return fmt.Errorf("failed to check existing node: %w", err)
 	}
+	if revision != 0 && existingRevision != revision {
+		return fmt.Errorf("%w: %s", ErrNodeConflict, node.Name)
+	}
+	if precondition != nil {
+		if err := precondition(existingNode); err != nil {
+			return err
+		}
+	}
 
-	// Update the node
-	if err := r.storage.Update(ctx, key, node); err != nil {
+	// Both timestamps are set by the server, whatever the update carries
+	node.CreationTimestamp = existingNode.CreationTimestamp
+	node.LastModified = r.clock.Now()
+	err = r.storage.UpdateIfRevision(ctx, key, node, existingRevision)
+	if errors.Is(err, storage.ErrConflict) {
+		return fmt.Errorf("%w: %s", ErrNodeConflict, node.Name)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to update node: %w", err)
 	}
 
+	r.history.record(node.Name, node.Status, node.LastModified)
+	r.notify(ctx, watch.Modified, node)
 	return nil
 }
 
-// DeleteNode removes a Node by name
+// DeleteNode removes a Node by name. Deleting a Node that doesn't exist succeeds
+// without telling watchers about it.
 func (r *NodeRegistry) DeleteNode(ctx context.Context, name string) error {
+	_, err := r.deleteNode(ctx, name)
+	return err
+}
+
+// deleteNode removes a Node by name in a single storage request and reports
+// whether it existed
+func (r *NodeRegistry) deleteNode(ctx context.Context, name string) (bool, error) {
+	name = r.normalizeName(name)
 	if name == "" {
-		return ErrNodeInvalid
+		return false, ErrNodeInvalid
 	}
 
-	key := generateKey(nodePrefix, name)
-	err := r.storage.Delete(ctx, key)
-	if err != nil && !errors.Is(err, storage.ErrNotFound) {
-		return fmt.Errorf("failed to delete node: %w", err)
+	key := generateKey(r.prefix, name)
+	deleted := &api.Node{}
+	err := r.storage.GetAndDelete(ctx, key, deleted)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete node: %w", err)
 	}
 
-	return nil
+	// Watchers get the Node as it was last stored, so selectors still match it
+	r.history.forget(name)
+	r.notify(ctx, watch.Deleted, deleted)
+	return true, nil
 }
 
 // ListNodes retrieves all Nodes
 func (r *NodeRegistry) ListNodes(ctx context.Context) ([]*api.Node, error) {
 	var nodes []*api.Node
-	err := r.storage.List(ctx, nodePrefix, &nodes)
+	err := r.storage.List(ctx, r.prefix, &nodes)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrListNodesFailed, err)
 	}
 
 	return nodes, nil
 }
+
+// StreamNodes calls fn for each Node in name order without loading all of them into memory
+func (r *NodeRegistry) StreamNodes(ctx context.Context, fn func(node *api.Node) error) error {
+	var fnErr error
+	newNode := func() runtime.Object { return &api.Node{} }
+	err := r.storage.Walk(ctx, r.prefix, newNode, func(obj runtime.Object) error {
+		fnErr = fn(obj.(*api.Node))
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrListNodesFailed, err)
+	}
+	return nil
+}
+
+// ListNodesByPhase retrieves all Nodes currently in the given phase
+func (r *NodeRegistry) ListNodesByPhase(ctx context.Context, phase api.NodePhase) ([]*api.Node, error) {
+	nodes, _, err := r.FilterNodes(ctx, NodeFilter{Phase: phase})
+	return nodes, err
+}
+
+// WatchNodes returns a watch that receives an event for every Node mutation made
+// through this registry. The watch is stopped when ctx is done.
+func (r *NodeRegistry) WatchNodes(ctx context.Context) (watch.Interface, error) {
+	var w watch.Interface = r.broadcaster.Watch()
+	if r.coalesceWindow > 0 {
+		w = watch.Coalesce(w, r.coalesceWindow, nodeName)
+	}
+	go func() {
+		<-ctx.Done()
+		w.Stop()
+	}()
+
+	return w, nil
+}
+
+// nodeName is the watch.KeyFunc for Node events
+func nodeName(obj runtime.Object) string {
+	if node, ok := obj.(*api.Node); ok {
+		return node.Name
+	}
+	return ""
+}
+
+// WatchNodesWithInitialEvents returns a watch that first receives an Added event for
+// every existing Node, then a Bookmark marking the end of the initial sync, then live
+// events. The watch starts before the Nodes are listed, so no change is lost between
+// the two, though a change made while listing may be delivered twice.
+func (r *NodeRegistry) WatchNodesWithInitialEvents(ctx context.Context) (watch.Interface, error) {
+	live, err := r.WatchNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := r.ListNodes(ctx)
+	if err != nil {
+		live.Stop()
+		return nil, err
+	}
+
+	initial := make([]watch.Event, 0, len(nodes)+1)
+	for _, node := range nodes {
+		initial = append(initial, watch.Event{Type: watch.Added, Object: node})
+	}
+	initial = append(initial, watch.Event{Type: watch.Bookmark, Object: &api.Node{
+		ObjectMeta: api.ObjectMeta{Annotations: map[string]string{watch.InitialEventsEndAnnotation: "true"}},
+	}})
+
+	return watch.Prepend(live, initial), nil
+}
+
+// WatchNode returns a watch that receives events only for the Node called name. Its
+// first event is an Added event with the current state of the Node, or a Deleted
+// event if the Node doesn't exist. Like WatchNodesWithInitialEvents, a change made
+// while the Node is read may be delivered twice.
+func (r *NodeRegistry) WatchNode(ctx context.Context, name string) (watch.Interface, error) {
+	name = r.normalizeName(name)
+	if name == "" {
+		return nil, ErrNodeInvalid
+	}
+
+	live, err := r.WatchNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live = watch.Filter(live, func(event watch.Event) bool {
+		return nodeName(event.Object) == name
+	})
+
+	initial := watch.Event{Type: watch.Added}
+	node, err := r.GetNode(ctx, name)
+	switch {
+	case err == nil:
+		initial.Object = node
+	case errors.Is(err, ErrNodeNotFound):
+		initial.Type = watch.Deleted
+		initial.Object = &api.Node{ObjectMeta: api.ObjectMeta{Name: name}}
+	default:
+		live.Stop()
+		return nil, err
+	}
+
+	return watch.Prepend(live, []watch.Event{initial}), nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,7 +15,11 @@ import (
 
 	mockStorage "gokube/mocks/pkg/storage"
 	"gokube/pkg/api"
+	"gokube/pkg/clock"
+	"gokube/pkg/labels"
+	"gokube/pkg/runtime"
 	"gokube/pkg/storage"
+	"gokube/pkg/watch"
 )
 
 func TestNewNodeRegistry(t *testing.T) {
@@ -22,6 +28,11 @@ func TestNewNodeRegistry(t *testing.T) {
 
 	assert.NotNil(t, nodeRegistry)
 	assert.Equal(t, etcdStorage, nodeRegistry.storage)
+
+	// Without storage the registry fails at construction, not on first use
+	assert.PanicsWithValue(t, "registry: NewNodeRegistry called with nil storage", func() {
+		NewNodeRegistry(nil)
+	})
 }
 
 func TestNodeRegistry_CreateNode(t *testing.T) {
@@ -57,6 +68,36 @@ func TestNodeRegistry_CreateNode(t *testing.T) {
 		})
 	})
 
+	t.Run("should let exactly one of many concurrent creates succeed", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			etcdStorage := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := NewNodeRegistry(etcdStorage)
+
+			const attempts = 20
+			errs := make(chan error, attempts)
+			var wg sync.WaitGroup
+			for i := 0; i < attempts; i++ {
+				wg.Add(1)
+				go func(uid int) {
+					defer wg.Done()
+					errs <- nodeRegistry.CreateNode(context.Background(), createTestNode("contended-node", fmt.Sprint(uid)))
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+
+			succeeded := 0
+			for err := range errs {
+				if err == nil {
+					succeeded++
+					continue
+				}
+				assert.ErrorIs(t, err, ErrNodeAlreadyExists)
+			}
+			assert.Equal(t, 1, succeeded)
+		})
+	})
+
 	t.Run("should fail to create invalid node", func(t *testing.T) {
 		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
 			etcdStorage := storage.NewEtcdStorage(etcdServer)
@@ -135,6 +176,23 @@ func TestNodeRegistry_UpdateNode(t *testing.T) {
 		})
 	})
 
+	t.Run("should keep the creation timestamp of a node replaced without one", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-3", "789")
+			created, err := nodeRegistry.GetNode(context.Background(), "test-node-3")
+			require.NoError(t, err)
+			require.False(t, created.CreationTimestamp.IsZero())
+
+			err = nodeRegistry.UpdateNode(context.Background(), createTestNode("test-node-3", "789"))
+			require.NoError(t, err)
+
+			updatedNode, err := nodeRegistry.GetNode(context.Background(), "test-node-3")
+			require.NoError(t, err)
+			assert.True(t, created.CreationTimestamp.Equal(updatedNode.CreationTimestamp))
+		})
+	})
+
 	t.Run("should fail to update invalid node", func(t *testing.T) {
 		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
 			etcdStorage := storage.NewEtcdStorage(etcdServer)
@@ -152,6 +210,153 @@ func TestNodeRegistry_UpdateNode(t *testing.T) {
 	})
 }
 
+// interleavingStorage runs beforeWrite, once, just before the first conditional
+// write, to simulate another writer racing the one under test
+type interleavingStorage struct {
+	storage.Storage
+	beforeWrite func()
+}
+
+func (s *interleavingStorage) UpdateIfRevision(ctx context.Context, key string, obj runtime.Object, revision int64) error {
+	if before := s.beforeWrite; before != nil {
+		s.beforeWrite = nil
+		before()
+	}
+	return s.Storage.UpdateIfRevision(ctx, key, obj, revision)
+}
+
+func TestNodeRegistry_UpdateNodeIfUnmodifiedSince(t *testing.T) {
+	t.Run("should fail if the node is modified after the precondition was checked", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			ctx := context.Background()
+			fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			racing := &interleavingStorage{Storage: storage.NewEtcdStorage(etcdServer)}
+			nodeRegistry := NewNodeRegistry(racing, WithClock(fakeClock))
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-15", "112")
+			since := fakeClock.Now()
+
+			racing.beforeWrite = func() {
+				fakeClock.Step(time.Minute)
+				other := createTestNode("test-node-15", "112")
+				other.Status = api.NodeNotReady
+				require.NoError(t, nodeRegistry.UpdateNode(ctx, other))
+			}
+
+			node := createTestNode("test-node-15", "112")
+			node.Status = api.NodeReady
+			err := nodeRegistry.UpdateNodeIfUnmodifiedSince(ctx, node, since)
+			assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+			stored, err := nodeRegistry.GetNode(ctx, "test-node-15")
+			require.NoError(t, err)
+			assert.Equal(t, api.NodeNotReady, stored.Status)
+		})
+	})
+}
+
+func TestNodeRegistry_UpdateNodeWithRetry(t *testing.T) {
+	t.Run("should retry after a concurrent modification", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			etcdStorage := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := NewNodeRegistry(etcdStorage)
+			ctx := context.Background()
+			nodeName := "test-node-11"
+			createTestNodeInRegistry(t, nodeRegistry, nodeName, "108")
+
+			attempts := 0
+			err := nodeRegistry.UpdateNodeWithRetry(ctx, nodeName, func(node *api.Node) error {
+				attempts++
+				if attempts == 1 {
+					// Someone else updates the node between our get and update
+					other, err := nodeRegistry.GetNode(ctx, nodeName)
+					require.NoError(t, err)
+					other.Labels = map[string]string{"zone": "a"}
+					require.NoError(t, nodeRegistry.UpdateNode(ctx, other))
+				}
+				node.Spec.Unschedulable = true
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, attempts)
+
+			updated, err := nodeRegistry.GetNode(ctx, nodeName)
+			require.NoError(t, err)
+			assert.True(t, updated.Spec.Unschedulable)
+			assert.Equal(t, map[string]string{"zone": "a"}, updated.Labels)
+		})
+	})
+
+	t.Run("should not lose concurrent updates made at the same time", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			// Every write gets the same LastModified, so only the storage revision
+			// tells the versions apart
+			fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithClock(fakeClock), WithUpdateRetries(100))
+			ctx := context.Background()
+			nodeName := "test-node-14"
+			createTestNodeInRegistry(t, nodeRegistry, nodeName, "111")
+
+			const writers = 10
+			var wg sync.WaitGroup
+			for i := 0; i < writers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					err := nodeRegistry.UpdateNodeWithRetry(ctx, nodeName, func(node *api.Node) error {
+						if node.Labels == nil {
+							node.Labels = map[string]string{}
+						}
+						node.Labels[fmt.Sprintf("writer-%d", i)] = "true"
+						return nil
+					})
+					assert.NoError(t, err)
+				}(i)
+			}
+			wg.Wait()
+
+			updated, err := nodeRegistry.GetNode(ctx, nodeName)
+			require.NoError(t, err)
+			assert.Len(t, updated.Labels, writers)
+		})
+	})
+
+	t.Run("should give up after the configured retries", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			etcdStorage := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := NewNodeRegistry(etcdStorage, WithUpdateRetries(1))
+			ctx := context.Background()
+			nodeName := "test-node-12"
+			createTestNodeInRegistry(t, nodeRegistry, nodeName, "109")
+
+			attempts := 0
+			err := nodeRegistry.UpdateNodeWithRetry(ctx, nodeName, func(node *api.Node) error {
+				attempts++
+				other, err := nodeRegistry.GetNode(ctx, nodeName)
+				require.NoError(t, err)
+				require.NoError(t, nodeRegistry.UpdateNode(ctx, other))
+				return nil
+			})
+			assert.ErrorIs(t, err, ErrNodeConflict)
+			assert.Equal(t, 2, attempts)
+		})
+	})
+
+	t.Run("should return the mutate error", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			etcdStorage := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := NewNodeRegistry(etcdStorage)
+			nodeName := "test-node-13"
+			createTestNodeInRegistry(t, nodeRegistry, nodeName, "110")
+
+			mutateErr := errors.New("mutate failed")
+			err := nodeRegistry.UpdateNodeWithRetry(context.Background(), nodeName, func(node *api.Node) error {
+				return mutateErr
+			})
+			assert.ErrorIs(t, err, mutateErr)
+		})
+	})
+}
+
 func TestNodeRegistry_ListNodes(t *testing.T) {
 	t.Run("should list nodes", func(t *testing.T) {
 		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
@@ -214,8 +419,446 @@ func TestDeleteNonExistentNode(t *testing.T) {
 		nodeRegistry := NewNodeRegistry(etcdStorage)
 		ctx := context.Background()
 
-		err := nodeRegistry.DeleteNode(ctx, "non-existent-node")
+		w, err := nodeRegistry.WatchNodes(ctx)
+		require.NoError(t, err)
+		defer w.Stop()
+		revision := nodeRegistry.Revision()
+
+		err = nodeRegistry.DeleteNode(ctx, "non-existent-node")
 		assert.NoError(t, err) // Deleting a non-existent node should not return an error
+
+		// Nor should it look like a deletion to watchers
+		assert.Equal(t, revision, nodeRegistry.Revision())
+		select {
+		case event := <-w.ResultChan():
+			t.Fatalf("unexpected %s event", event.Type)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+func TestNodeRegistry_DeleteNodesByName(t *testing.T) {
+	t.Run("should delete each node with a single storage request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mStorage := mockStorage.NewMockStorage(ctrl)
+		nodeRegistry := NewNodeRegistry(mStorage)
+		ctx := context.Background()
+
+		mStorage.EXPECT().GetAndDelete(ctx, generateKey(nodePrefix, "test-node"), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, obj runtime.Object) error {
+				obj.(*api.Node).Name = "test-node"
+				return nil
+			})
+		mStorage.EXPECT().GetAndDelete(ctx, generateKey(nodePrefix, "missing-node"), gomock.Any()).Return(storage.ErrNotFound)
+		mStorage.EXPECT().GetAndDelete(ctx, generateKey(nodePrefix, "broken-node"), gomock.Any()).Return(errors.New("storage error"))
+
+		results := nodeRegistry.DeleteNodesByName(ctx, []string{"test-node", "missing-node", "broken-node"})
+
+		require.Len(t, results, 3)
+		assert.Equal(t, DeleteResult{Name: "test-node", Status: DeleteStatusDeleted}, results[0])
+		assert.Equal(t, DeleteResult{Name: "missing-node", Status: DeleteStatusNotFound}, results[1])
+		assert.Equal(t, DeleteStatusFailed, results[2].Status)
+		assert.Contains(t, results[2].Error, "storage error")
+	})
+}
+
+func TestNodeRegistry_DeleteNodeEvent(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		node := createTestNode("test-node-16", "113")
+		node.Labels = map[string]string{"zone": "a"}
+		require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+		w, err := nodeRegistry.WatchNodes(ctx)
+		require.NoError(t, err)
+		defer w.Stop()
+		require.NoError(t, nodeRegistry.DeleteNode(ctx, "test-node-16"))
+
+		// The event carries the Node as it was stored, so selectors can match it
+		event := <-w.ResultChan()
+		assert.Equal(t, watch.Deleted, event.Type)
+		deleted := event.Object.(*api.Node)
+		assert.Equal(t, "test-node-16", deleted.Name)
+		assert.Equal(t, map[string]string{"zone": "a"}, deleted.Labels)
+	})
+}
+
+func TestNodeRegistry_KeyPrefix(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		etcdStorage := storage.NewEtcdStorage(etcdServer)
+		registryA := NewNodeRegistry(etcdStorage, WithKeyPrefix("/cluster-a/nodes"))
+		registryB := NewNodeRegistry(etcdStorage, WithKeyPrefix("/cluster-b/nodes/"))
+		ctx := context.Background()
+
+		createTestNodeInRegistry(t, registryA, "shared-name", "a")
+		createTestNodeInRegistry(t, registryA, "only-in-a", "a")
+		createTestNodeInRegistry(t, registryB, "shared-name", "b")
+
+		nodesA, err := registryA.ListNodes(ctx)
+		require.NoError(t, err)
+		assert.Len(t, nodesA, 2)
+
+		nodesB, err := registryB.ListNodes(ctx)
+		require.NoError(t, err)
+		require.Len(t, nodesB, 1)
+		assert.Equal(t, "b", nodesB[0].UID)
+
+		_, err = registryB.GetNode(ctx, "only-in-a")
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+
+		require.NoError(t, registryB.DeleteNode(ctx, "shared-name"))
+		node, err := registryA.GetNode(ctx, "shared-name")
+		require.NoError(t, err)
+		assert.Equal(t, "a", node.UID)
+	})
+}
+
+func TestNodeRegistry_WatchNodes(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		etcdStorage := storage.NewEtcdStorage(etcdServer)
+		nodeRegistry := NewNodeRegistry(etcdStorage, WithWatchBufferSize(10))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		w, err := nodeRegistry.WatchNodes(ctx)
+		require.NoError(t, err)
+
+		createTestNodeInRegistry(t, nodeRegistry, "test-node-7", "104")
+		require.NoError(t, nodeRegistry.DeleteNode(ctx, "test-node-7"))
+
+		for _, expected := range []watch.EventType{watch.Added, watch.Deleted} {
+			select {
+			case event := <-w.ResultChan():
+				assert.Equal(t, expected, event.Type)
+				assert.Equal(t, "test-node-7", event.Object.(*api.Node).Name)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %s event", expected)
+			}
+		}
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			_, ok := <-w.ResultChan()
+			return !ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestNodeRegistry_WatchNodesCoalescing(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		etcdStorage := storage.NewEtcdStorage(etcdServer)
+		nodeRegistry := NewNodeRegistry(etcdStorage, WithWatchCoalescing(500*time.Millisecond))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		node := createTestNode("test-node-heartbeat", "108")
+		require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+		w, err := nodeRegistry.WatchNodes(ctx)
+		require.NoError(t, err)
+
+		for _, status := range []api.NodeStatus{api.NodeNotReady, api.NodeReady, api.NodeMemoryPressure} {
+			node.Status = status
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+		}
+
+		select {
+		case event := <-w.ResultChan():
+			assert.Equal(t, watch.Modified, event.Type)
+			assert.Equal(t, api.NodeMemoryPressure, event.Object.(*api.Node).Status)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the coalesced event")
+		}
+
+		select {
+		case event := <-w.ResultChan():
+			t.Fatalf("unexpected %s event", event.Type)
+		case <-time.After(time.Second):
+		}
+	})
+}
+
+func TestNodeRegistry_GetStatusHistory(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithClock(fakeClock), WithStatusHistorySize(3))
+		ctx := context.Background()
+
+		node := createTestNode("test-node-flapping", "109")
+		node.Status = api.NodeNotReady
+		require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+		t.Run("should record transitions in order", func(t *testing.T) {
+			fakeClock.Step(time.Minute)
+			node.Status = api.NodeReady
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+
+			// Updates that keep the status are not transitions
+			require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+
+			history, err := nodeRegistry.GetStatusHistory(ctx, node.Name)
+			require.NoError(t, err)
+			assert.Equal(t, []StatusTransition{
+				{To: api.NodeNotReady, Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{From: api.NodeNotReady, To: api.NodeReady, Time: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)},
+			}, history)
+		})
+
+		t.Run("should drop the oldest transitions when full", func(t *testing.T) {
+			for _, status := range []api.NodeStatus{api.NodeNotReady, api.NodeReady, api.NodeNotReady} {
+				fakeClock.Step(time.Minute)
+				node.Status = status
+				require.NoError(t, nodeRegistry.UpdateNode(ctx, node))
+			}
+
+			history, err := nodeRegistry.GetStatusHistory(ctx, node.Name)
+			require.NoError(t, err)
+			require.Len(t, history, 3)
+			for i, transition := range history {
+				assert.Equal(t, time.Date(2024, 1, 1, 0, i+2, 0, 0, time.UTC), transition.Time)
+			}
+			assert.Equal(t, api.NodeNotReady, history[2].To)
+			assert.Equal(t, api.NodeReady, history[2].From)
+		})
+
+		t.Run("should forget deleted nodes", func(t *testing.T) {
+			require.NoError(t, nodeRegistry.DeleteNode(ctx, node.Name))
+
+			_, err := nodeRegistry.GetStatusHistory(ctx, node.Name)
+			assert.ErrorIs(t, err, ErrNodeNotFound)
+
+			require.NoError(t, nodeRegistry.CreateNode(ctx, createTestNode(node.Name, "110")))
+			history, err := nodeRegistry.GetStatusHistory(ctx, node.Name)
+			require.NoError(t, err)
+			assert.Len(t, history, 1)
+		})
+	})
+}
+
+func TestNodeRegistry_ListNodesByVersionSkew(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		for name, version := range map[string]string{"old": "v1.27.4", "current": "v1.29.1", "new": "v1.31.0", "unreported": ""} {
+			node := createTestNode("test-node-"+name, name)
+			if version != "" {
+				node.Info = &api.NodeInfo{KubeletVersion: version}
+			}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+		}
+
+		names := func(nodes []*api.Node) []string {
+			var names []string
+			for _, node := range nodes {
+				names = append(names, node.Name)
+			}
+			return names
+		}
+
+		t.Run("should return nodes outside the range", func(t *testing.T) {
+			nodes, err := nodeRegistry.ListNodesByVersionSkew(ctx, "v1.28.0", "v1.30.0")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"test-node-new", "test-node-old"}, names(nodes))
+		})
+
+		t.Run("should leave an empty bound open", func(t *testing.T) {
+			nodes, err := nodeRegistry.ListNodesByVersionSkew(ctx, "1.28", "")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"test-node-old"}, names(nodes))
+		})
+
+		t.Run("should reject invalid bounds", func(t *testing.T) {
+			_, err := nodeRegistry.ListNodesByVersionSkew(ctx, "latest", "")
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+
+		t.Run("should reject nodes reporting invalid versions", func(t *testing.T) {
+			node := createTestNode("test-node-invalid", "invalid")
+			node.Info = &api.NodeInfo{KubeletVersion: "latest"}
+			err := nodeRegistry.CreateNode(ctx, node)
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+		})
+	})
+}
+
+func TestNodeRegistry_WaitForChange(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		t.Run("should return immediately for an older revision", func(t *testing.T) {
+			since := nodeRegistry.Revision()
+			createTestNodeInRegistry(t, nodeRegistry, "test-node", "1")
+
+			changed, err := nodeRegistry.WaitForChange(ctx, since)
+			require.NoError(t, err)
+			assert.True(t, changed)
+		})
+
+		t.Run("should report no change when ctx is done first", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			changed, err := nodeRegistry.WaitForChange(ctx, nodeRegistry.Revision())
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	})
+}
+
+func TestNodeRegistry_StreamNodes(t *testing.T) {
+	t.Run("should stream nodes", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			etcdStorage := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := NewNodeRegistry(etcdStorage)
+			ctx := context.Background()
+
+			clearNodes(t, nodeRegistry)
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-8", "105")
+			createTestNodeInRegistry(t, nodeRegistry, "test-node-9", "106")
+
+			var names []string
+			err := nodeRegistry.StreamNodes(ctx, func(node *api.Node) error {
+				names = append(names, node.Name)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"test-node-8", "test-node-9"}, names)
+		})
+	})
+
+	t.Run("should handle error returned by the storage provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mStorage := mockStorage.NewMockStorage(ctrl)
+		nodeRegistry := NewNodeRegistry(mStorage)
+		ctx := context.Background()
+
+		mStorage.EXPECT().Walk(ctx, nodePrefix, gomock.Any(), gomock.Any()).Return(errors.New("failed to walk nodes"))
+
+		err := nodeRegistry.StreamNodes(ctx, func(node *api.Node) error { return nil })
+
+		assert.ErrorIs(t, err, ErrListNodesFailed)
+	})
+}
+
+func TestNodeRegistry_LabelNodes(t *testing.T) {
+	t.Run("should add and remove labels on matching nodes", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			etcdStorage := storage.NewEtcdStorage(etcdServer)
+			nodeRegistry := NewNodeRegistry(etcdStorage)
+			ctx := context.Background()
+
+			clearNodes(t, nodeRegistry)
+			node := createTestNode("test-node-10", "107")
+			node.Labels = map[string]string{"zone": "a", "old": "x"}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			selector, err := labels.Parse("zone=a")
+			require.NoError(t, err)
+
+			results, err := nodeRegistry.LabelNodes(ctx, selector, map[string]string{"zone": "a"}, []string{"old"})
+			require.NoError(t, err)
+			assert.Equal(t, []LabelResult{{Name: "test-node-10", Changed: true}}, results)
+
+			updated, err := nodeRegistry.GetNode(ctx, "test-node-10")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"zone": "a"}, updated.Labels)
+
+			// Applying the same delta again changes nothing
+			results, err = nodeRegistry.LabelNodes(ctx, selector, map[string]string{"zone": "a"}, []string{"old"})
+			require.NoError(t, err)
+			assert.Equal(t, []LabelResult{{Name: "test-node-10"}}, results)
+		})
+	})
+
+	t.Run("should keep labels written after the nodes were listed", func(t *testing.T) {
+		storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+			ctx := context.Background()
+			racing := &interleavingStorage{Storage: storage.NewEtcdStorage(etcdServer)}
+			nodeRegistry := NewNodeRegistry(racing)
+
+			node := createTestNode("test-node-16", "113")
+			node.Labels = map[string]string{"zone": "a"}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			racing.beforeWrite = func() {
+				require.NoError(t, nodeRegistry.UpdateNodeWithRetry(ctx, "test-node-16", func(node *api.Node) error {
+					node.Labels["rack"] = "r1"
+					return nil
+				}))
+			}
+
+			selector, err := labels.Parse("zone=a")
+			require.NoError(t, err)
+			results, err := nodeRegistry.LabelNodes(ctx, selector, map[string]string{"tier": "gold"}, nil)
+			require.NoError(t, err)
+			assert.Equal(t, []LabelResult{{Name: "test-node-16", Changed: true}}, results)
+
+			updated, err := nodeRegistry.GetNode(ctx, "test-node-16")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"zone": "a", "rack": "r1", "tier": "gold"}, updated.Labels)
+		})
+	})
+}
+
+func TestNodeRegistry_CountNodesByLabel(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer))
+		ctx := context.Background()
+
+		for i, zone := range []string{"a", "b", "a", "", "a"} {
+			node := createTestNode(fmt.Sprintf("test-node-%d", i), fmt.Sprintf("%d", i))
+			if zone != "" {
+				node.Labels = map[string]string{"zone": zone}
+			}
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+		}
+
+		counts, err := nodeRegistry.CountNodesByLabel(ctx, "zone")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"a": 3, "b": 1}, counts)
+
+		counts, err = nodeRegistry.CountNodesByLabel(ctx, "rack")
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+}
+
+func TestNodeRegistry_CordonNode(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		etcdStorage := storage.NewEtcdStorage(etcdServer)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		nodeRegistry := NewNodeRegistry(etcdStorage, WithClock(clock.NewFakeClock(now)))
+		ctx := context.Background()
+		nodeName := "test-node-14"
+		createTestNodeInRegistry(t, nodeRegistry, nodeName, "111")
+
+		require.NoError(t, nodeRegistry.CordonNode(ctx, nodeName, "kernel upgrade", "ops"))
+
+		cordoned, err := nodeRegistry.GetNode(ctx, nodeName)
+		require.NoError(t, err)
+		assert.True(t, cordoned.Spec.Unschedulable)
+		assert.Equal(t, "kernel upgrade", cordoned.Spec.CordonReason)
+		assert.Equal(t, "ops", cordoned.Spec.CordonedBy)
+		assert.True(t, now.Equal(cordoned.Spec.CordonedAt))
+
+		require.NoError(t, nodeRegistry.UncordonNode(ctx, nodeName))
+
+		uncordoned, err := nodeRegistry.GetNode(ctx, nodeName)
+		require.NoError(t, err)
+		assert.False(t, uncordoned.Spec.Unschedulable)
+		assert.Empty(t, uncordoned.Spec.CordonReason)
+		assert.Empty(t, uncordoned.Spec.CordonedBy)
+		assert.True(t, uncordoned.Spec.CordonedAt.IsZero())
+
+		assert.ErrorIs(t, nodeRegistry.CordonNode(ctx, "non-existent-node", "", ""), ErrNodeNotFound)
 	})
 }
 
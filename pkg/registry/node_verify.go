@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"gokube/pkg/api"
+	"gokube/pkg/runtime"
+)
+
+// InvalidKey is a storage key under the Node prefix that doesn't hold a valid Node
+type InvalidKey struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// VerifyReport is the result of VerifyStore
+type VerifyReport struct {
+	// Checked is the number of keys that were read
+	Checked int          `json:"checked"`
+	Invalid []InvalidKey `json:"invalid"`
+}
+
+// VerifyStore reads every key under the Node prefix and reports the ones whose value
+// doesn't decode into a Node, fails validation or is stored under a key that
+// doesn't match its name. Nothing is deleted or repaired; the report is meant for an
+// operator to act on.
+func (r *NodeRegistry) VerifyStore(ctx context.Context) (*VerifyReport, error) {
+	report := &VerifyReport{Invalid: []InvalidKey{}}
+	invalid := func(key, format string, args ...interface{}) {
+		report.Invalid = append(report.Invalid, InvalidKey{Key: key, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	err := r.storage.Scan(ctx, r.prefix, func() runtime.Object { return &api.Node{} }, func(key string, obj runtime.Object, err error) error {
+		report.Checked++
+		if err != nil {
+			invalid(key, "%v", err)
+			return nil
+		}
+
+		node := obj.(*api.Node)
+		if err := r.validate(node); err != nil {
+			invalid(key, "%v", err)
+			return nil
+		}
+		if expected := generateKey(r.prefix, node.Name); key != expected {
+			invalid(key, "node %s belongs under key %s", node.Name, expected)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return report, nil
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/storage"
+)
+
+func TestNodeRegistry_VerifyStore(t *testing.T) {
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		// Scans of stored Nodes aren't validation failures of writes
+		var observed []string
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer), WithValidationObserver(func(field string) {
+			observed = append(observed, field)
+		}))
+		ctx := context.Background()
+
+		createTestNodeInRegistry(t, nodeRegistry, "test-node-good", "1")
+		_, err := etcdServer.Put(ctx, nodePrefix+"test-node-corrupt", `{"metadata": {"name": `)
+		require.NoError(t, err)
+		_, err = etcdServer.Put(ctx, nodePrefix+"test-node-moved", `{"metadata": {"name": "test-node-elsewhere"}}`)
+		require.NoError(t, err)
+		_, err = etcdServer.Put(ctx, nodePrefix+"test-node-unnamed", `{"metadata": {}}`)
+		require.NoError(t, err)
+
+		report, err := nodeRegistry.VerifyStore(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Checked)
+		assert.Empty(t, observed)
+
+		var keys []string
+		for _, invalid := range report.Invalid {
+			keys = append(keys, invalid.Key)
+			assert.NotEmpty(t, invalid.Reason)
+		}
+		assert.Equal(t, []string{
+			nodePrefix + "test-node-corrupt",
+			nodePrefix + "test-node-moved",
+			nodePrefix + "test-node-unnamed",
+		}, keys)
+		assert.Contains(t, report.Invalid[1].Reason, "belongs under key "+nodePrefix+"test-node-elsewhere")
+
+		// Nothing is deleted
+		resp, err := etcdServer.Get(ctx, nodePrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), resp.Count)
+	})
+}
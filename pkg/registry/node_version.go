@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"gokube/pkg/api"
+)
+
+// ListNodesByVersionSkew returns the Nodes whose kubelet version is older than min
+// or newer than max. An empty bound is not checked. Nodes that haven't reported a
+// kubelet version can't be placed and are not returned.
+func (r *NodeRegistry) ListNodesByVersionSkew(ctx context.Context, min, max string) ([]*api.Node, error) {
+	minVersion, err := parseBound(min)
+	if err != nil {
+		return nil, err
+	}
+	maxVersion, err := parseBound(max)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := r.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	skewed := make([]*api.Node, 0)
+	for _, node := range nodes {
+		if node.Info == nil || node.Info.KubeletVersion == "" {
+			continue
+		}
+		version, err := api.ParseVersion(node.Info.KubeletVersion)
+		if err != nil {
+			// Validation keeps these out, unless they were stored before it did
+			continue
+		}
+		if (minVersion != nil && version.Compare(*minVersion) < 0) || (maxVersion != nil && version.Compare(*maxVersion) > 0) {
+			skewed = append(skewed, node)
+		}
+	}
+	return skewed, nil
+}
+
+// parseBound parses an optional version bound, returning nil for ""
+func parseBound(s string) (*api.Version, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := api.ParseVersion(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeInvalid, err)
+	}
+	return &v, nil
+}
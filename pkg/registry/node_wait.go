@@ -0,0 +1,34 @@
+package registry
+
+import "context"
+
+// Revision counts the Node mutations made through this registry. It changes
+// whenever a list of Nodes could have changed, so a client can pass the revision of
+// its last list to WaitForChange. Like watches, it is local to this process and
+// starts from 0 when the registry is created.
+func (r *NodeRegistry) Revision() uint64 {
+	return r.revision.Load()
+}
+
+// WaitForChange blocks until a Node has been mutated after revision since or ctx is
+// done, and reports whether there was a change. A revision other than the current
+// one returns immediately: it is either behind, or from before a restart.
+func (r *NodeRegistry) WaitForChange(ctx context.Context, since uint64) (bool, error) {
+	// Watch before checking the revision, so that a change made in between is seen
+	// by one or the other
+	w, err := r.WatchNodes(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer w.Stop()
+
+	if r.Revision() != since {
+		return true, nil
+	}
+	select {
+	case _, ok := <-w.ResultChan():
+		return ok, nil
+	case <-ctx.Done():
+		return false, nil
+	}
+}
@@ -1,5 +1,68 @@
 package registry
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"gokube/pkg/api"
+)
 
 var ErrInternal = errors.New("internal error")
+
+// StatusCode maps a registry error to the HTTP status code that describes it
+func StatusCode(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNodeInvalid), errors.Is(err, ErrTokenInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNodeAlreadyExists), errors.Is(err, ErrNodeConflict), errors.Is(err, ErrTokenAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrAdmissionDenied), errors.Is(err, ErrPatchInvalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		// ErrInternal, ErrListNodesFailed and anything unclassified
+		return http.StatusInternalServerError
+	}
+}
+
+// Reason returns the api.StatusReason for err, telling apart failures that share a
+// status code, such as ErrNodeAlreadyExists and ErrNodeConflict. It returns
+// api.StatusReasonUnknown for errors it doesn't know, which are then described by
+// their status code alone.
+func Reason(err error) api.StatusReason {
+	switch {
+	case errors.Is(err, ErrNodeNotFound):
+		return api.StatusReasonNotFound
+	case errors.Is(err, ErrNodeInvalid), errors.Is(err, ErrTokenInvalid):
+		return api.StatusReasonBadRequest
+	case errors.Is(err, ErrNodeAlreadyExists), errors.Is(err, ErrTokenAlreadyExists):
+		return api.StatusReasonAlreadyExists
+	case errors.Is(err, ErrNodeConflict):
+		return api.StatusReasonConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		return api.StatusReasonPreconditionFailed
+	case errors.Is(err, ErrAdmissionDenied), errors.Is(err, ErrPatchInvalid):
+		return api.StatusReasonInvalid
+	case errors.Is(err, context.DeadlineExceeded):
+		return api.StatusReasonTimeout
+	case errors.Is(err, ErrInternal), errors.Is(err, ErrListNodesFailed):
+		return api.StatusReasonInternalError
+	default:
+		return api.StatusReasonUnknown
+	}
+}
+
+// IsRetryable reports whether the operation that returned err may succeed if
+// retried unchanged. Server-side failures and timeouts are retryable; errors
+// caused by the request itself are not.
+func IsRetryable(err error) bool {
+	return StatusCode(err) >= http.StatusInternalServerError
+}
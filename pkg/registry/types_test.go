@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gokube/pkg/api"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantRetryable bool
+		wantReason    api.StatusReason
+	}{
+		{
+			name:          "no error",
+			err:           nil,
+			wantStatus:    http.StatusOK,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonUnknown,
+		},
+		{
+			name:          "node not found",
+			err:           ErrNodeNotFound,
+			wantStatus:    http.StatusNotFound,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonNotFound,
+		},
+		{
+			name:          "invalid node",
+			err:           ErrNodeInvalid,
+			wantStatus:    http.StatusBadRequest,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonBadRequest,
+		},
+		{
+			name:          "node already exists",
+			err:           ErrNodeAlreadyExists,
+			wantStatus:    http.StatusConflict,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonAlreadyExists,
+		},
+		{
+			name:          "node conflict",
+			err:           fmt.Errorf("%w: test-node", ErrNodeConflict),
+			wantStatus:    http.StatusConflict,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonConflict,
+		},
+		{
+			name:          "precondition failed",
+			err:           ErrPreconditionFailed,
+			wantStatus:    http.StatusPreconditionFailed,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonPreconditionFailed,
+		},
+		{
+			name:          "admission denied",
+			err:           fmt.Errorf("%w: missing labels", ErrAdmissionDenied),
+			wantStatus:    http.StatusUnprocessableEntity,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonInvalid,
+		},
+		{
+			name:          "patch invalid",
+			err:           fmt.Errorf("%w: bad label", ErrPatchInvalid),
+			wantStatus:    http.StatusUnprocessableEntity,
+			wantRetryable: false,
+			wantReason:    api.StatusReasonInvalid,
+		},
+		{
+			name:          "list nodes failed",
+			err:           fmt.Errorf("%w: storage unavailable", ErrListNodesFailed),
+			wantStatus:    http.StatusInternalServerError,
+			wantRetryable: true,
+			wantReason:    api.StatusReasonInternalError,
+		},
+		{
+			name:          "internal error",
+			err:           ErrInternal,
+			wantStatus:    http.StatusInternalServerError,
+			wantRetryable: true,
+			wantReason:    api.StatusReasonInternalError,
+		},
+		{
+			name:          "timeout",
+			err:           fmt.Errorf("failed to update node: %w", context.DeadlineExceeded),
+			wantStatus:    http.StatusGatewayTimeout,
+			wantRetryable: true,
+			wantReason:    api.StatusReasonTimeout,
+		},
+		{
+			name:          "unclassified error",
+			err:           errors.New("storage error"),
+			wantStatus:    http.StatusInternalServerError,
+			wantRetryable: true,
+			wantReason:    api.StatusReasonUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, StatusCode(tt.err))
+			assert.Equal(t, tt.wantRetryable, IsRetryable(tt.err))
+			assert.Equal(t, tt.wantReason, Reason(tt.err))
+		})
+	}
+}
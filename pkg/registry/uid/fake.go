@@ -0,0 +1,29 @@
+package uid
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeGenerator is a Generator for tests that counts instead of picking random
+// bytes, so the UIDs it generates are predictable. They have the same form as
+// RandomGenerator's.
+type FakeGenerator struct {
+	mu    sync.Mutex
+	count int
+}
+
+// NewFakeGenerator returns a FakeGenerator whose first UID is
+// 00000000-0000-0000-0000-000000000001
+func NewFakeGenerator() *FakeGenerator {
+	return &FakeGenerator{}
+}
+
+// NewUID implements Generator
+func (g *FakeGenerator) NewUID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.count++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", g.count)
+}
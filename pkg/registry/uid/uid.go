@@ -0,0 +1,29 @@
+// Package uid generates the UIDs that tell apart objects which had the same name
+// at different times
+package uid
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Generator generates UIDs for new objects
+type Generator interface {
+	// NewUID returns a UID that no other object has been given
+	NewUID() string
+}
+
+// randomGenerator generates random (version 4) UUIDs
+type randomGenerator struct{}
+
+// RandomGenerator is the Generator used unless another is configured. Its UIDs are
+// random UUIDs such as "9b2f1c4e-3a6d-4f8b-a1c2-7e5d0f3b9a64".
+var RandomGenerator Generator = randomGenerator{}
+
+func (randomGenerator) NewUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
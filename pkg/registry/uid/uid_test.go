@@ -0,0 +1,34 @@
+package uid
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRandomGenerator(t *testing.T) {
+	t.Run("should generate version 4 UUIDs", func(t *testing.T) {
+		assert.Regexp(t, uuidPattern, RandomGenerator.NewUID())
+	})
+
+	t.Run("should not repeat itself", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			uid := RandomGenerator.NewUID()
+			assert.False(t, seen[uid], uid)
+			seen[uid] = true
+		}
+	})
+}
+
+func TestFakeGenerator(t *testing.T) {
+	t.Run("should count up from one", func(t *testing.T) {
+		g := NewFakeGenerator()
+
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", g.NewUID())
+		assert.Equal(t, "00000000-0000-0000-0000-000000000002", g.NewUID())
+	})
+}
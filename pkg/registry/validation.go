@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gokube/pkg/api"
+)
+
+// ValidationFunc checks a Node for a cluster-specific rule and returns an error,
+// ideally built with api.FieldError, for every field that breaks it
+type ValidationFunc func(node *api.Node) []error
+
+// WithValidation adds validation functions that run after Node.Validate on every
+// create and update, so operators can enforce their own rules on top of the
+// built-in ones
+func WithValidation(fns ...ValidationFunc) Option {
+	return func(r *NodeRegistry) {
+		r.validators = append(r.validators, fns...)
+	}
+}
+
+// WithValidationObserver sets a function that is called for every validation
+// failure of a create, update or apply with the kind of the field that failed, see api.FieldValidationError, or
+// "unknown" for failures that don't name a field. It is meant for counting which
+// rules reject the most Nodes.
+func WithValidationObserver(observe func(field string)) Option {
+	return func(r *NodeRegistry) {
+		r.observeValidation = observe
+	}
+}
+
+// validate runs Node.Validate and every registered ValidationFunc. All failures are
+// reported together, so a client can fix them in one go. It doesn't tell the
+// validation observer, so that checks of stored Nodes such as VerifyStore aren't
+// counted; writes use validateWrite.
+func (r *NodeRegistry) validate(node *api.Node) error {
+	return joinValidationErrors(r.validationErrors(node))
+}
+
+// validateWrite validates a Node about to be written like validate, and tells the
+// validation observer the field of every failure
+func (r *NodeRegistry) validateWrite(node *api.Node) error {
+	errs := r.validationErrors(node)
+	if r.observeValidation != nil {
+		for _, err := range errs {
+			field := "unknown"
+			var fieldErr *api.FieldValidationError
+			if errors.As(err, &fieldErr) {
+				field = fieldErr.FieldKind()
+			}
+			r.observeValidation(field)
+		}
+	}
+	return joinValidationErrors(errs)
+}
+
+// validationErrors returns every failure of Node.Validate and the registered
+// ValidationFuncs
+func (r *NodeRegistry) validationErrors(node *api.Node) []error {
+	var errs []error
+	if err := node.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, fn := range r.validators {
+		errs = append(errs, fn(node)...)
+	}
+	return errs
+}
+
+// joinValidationErrors returns the only error of errs, or one error listing all of
+// them
+func joinValidationErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Errorf("%w: %s", api.ErrInvalidNodeSpec, strings.Join(messages, "; "))
+}
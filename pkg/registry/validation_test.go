@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/storage"
+)
+
+func TestNodeRegistry_WithValidation(t *testing.T) {
+	forbidLabel := func(key string) ValidationFunc {
+		return func(node *api.Node) []error {
+			if _, ok := node.Labels[key]; ok {
+				return []error{api.FieldError(fmt.Sprintf("metadata.labels[%s]", key), "label is not allowed in this cluster")}
+			}
+			return nil
+		}
+	}
+
+	storage.TestWithEmbeddedEtcd(t, func(t *testing.T, etcdServer *clientv3.Client) {
+		nodeRegistry := NewNodeRegistry(storage.NewEtcdStorage(etcdServer),
+			WithValidation(forbidLabel("legacy"), forbidLabel("debug")))
+		ctx := context.Background()
+
+		t.Run("should reject a node a custom validator rejects", func(t *testing.T) {
+			node := createTestNode("test-node", "1")
+			node.Labels = map[string]string{"legacy": "true"}
+
+			err := nodeRegistry.CreateNode(ctx, node)
+			assert.ErrorIs(t, err, ErrNodeInvalid)
+			assert.Contains(t, err.Error(), "metadata.labels[legacy]")
+
+			_, err = nodeRegistry.GetNode(ctx, "test-node")
+			assert.ErrorIs(t, err, ErrNodeNotFound)
+		})
+
+		t.Run("should report every failing validator", func(t *testing.T) {
+			node := createTestNode("test-node", "1")
+			node.Labels = map[string]string{"legacy": "true", "debug": "true"}
+
+			err := nodeRegistry.CreateNode(ctx, node)
+			require.ErrorIs(t, err, ErrNodeInvalid)
+			assert.Contains(t, err.Error(), "metadata.labels[legacy]")
+			assert.Contains(t, err.Error(), "metadata.labels[debug]")
+		})
+
+		t.Run("should enforce custom validators on update", func(t *testing.T) {
+			node := createTestNode("test-node", "1")
+			require.NoError(t, nodeRegistry.CreateNode(ctx, node))
+
+			node.Labels = map[string]string{"debug": "true"}
+			assert.ErrorIs(t, nodeRegistry.UpdateNode(ctx, node), ErrNodeInvalid)
+		})
+	})
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gokube/pkg/clock"
+)
+
+// CompactionPolicy says which history compaction keeps. A revision is only
+// discarded once it is outside both limits; a zero limit doesn't keep anything.
+type CompactionPolicy struct {
+	// KeepRevisions is the number of most recent revisions to keep
+	KeepRevisions int
+	// KeepFor keeps every revision written within this long
+	KeepFor time.Duration
+}
+
+// revisionSample is the revision a store was at, at some point in time
+type revisionSample struct {
+	at       time.Time
+	revision int64
+}
+
+// compactor compacts a Storage according to a CompactionPolicy. Storage has no
+// notion of when a revision was written, so the compactor samples the current
+// revision each time it runs and measures age at that granularity.
+type compactor struct {
+	storage Storage
+	policy  CompactionPolicy
+	clock   clock.Clock
+	samples []revisionSample
+}
+
+// RunCompactor compacts s every interval according to policy until ctx is done.
+// Failed compactions are logged and retried at the next tick. s is compacted as a
+// whole, see Storage.Compact.
+func RunCompactor(ctx context.Context, s Storage, interval time.Duration, policy CompactionPolicy) {
+	c := &compactor{storage: s, policy: policy, clock: clock.RealClock{}}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.compact(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Error compacting storage: %v", err)
+			}
+		}
+	}
+}
+
+// compact records the current revision and compacts everything the policy no
+// longer keeps
+func (c *compactor) compact(ctx context.Context) error {
+	current, err := c.storage.Revision(ctx)
+	if err != nil {
+		return err
+	}
+	now := c.clock.Now()
+	c.samples = append(c.samples, revisionSample{at: now, revision: current})
+
+	keep := int64(c.policy.KeepRevisions)
+	if c.policy.KeepFor > 0 {
+		// Keep everything since the newest sample that is old enough
+		old := -1
+		for i, sample := range c.samples {
+			if now.Sub(sample.at) >= c.policy.KeepFor {
+				old = i
+			}
+		}
+		if old < 0 {
+			return nil
+		}
+		keep = max(keep, current-c.samples[old].revision)
+		// Older samples are compacted away and never needed again
+		c.samples = c.samples[old:]
+	}
+
+	return c.storage.Compact(ctx, int(keep))
+}
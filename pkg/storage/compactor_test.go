@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/clock"
+)
+
+func TestCompactor(t *testing.T) {
+	t.Run("should keep revisions younger than KeepFor", func(t *testing.T) {
+		TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+			storage := NewEtcdStorage(cli)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			c := &compactor{storage: storage, policy: CompactionPolicy{KeepFor: time.Hour}, clock: fakeClock}
+
+			require.NoError(t, storage.Create(ctx, "test-key", &TestObject{Name: "first"}))
+			oldRev, err := storage.Revision(ctx)
+			require.NoError(t, err)
+
+			// Nothing is an hour old yet
+			require.NoError(t, c.compact(ctx))
+			_, err = cli.Get(ctx, "test-key", clientv3.WithRev(oldRev))
+			require.NoError(t, err)
+
+			fakeClock.Step(30 * time.Minute)
+			require.NoError(t, storage.Update(ctx, "test-key", &TestObject{Name: "second"}))
+			halfHourRev, err := storage.Revision(ctx)
+			require.NoError(t, err)
+			require.NoError(t, c.compact(ctx))
+
+			// oldRev is now an hour old, halfHourRev isn't
+			fakeClock.Step(30 * time.Minute)
+			require.NoError(t, storage.Update(ctx, "test-key", &TestObject{Name: "third"}))
+			require.NoError(t, c.compact(ctx))
+
+			_, err = cli.Get(ctx, "test-key", clientv3.WithRev(oldRev))
+			require.NoError(t, err)
+			_, err = cli.Get(ctx, "test-key", clientv3.WithRev(oldRev-1))
+			assert.ErrorIs(t, err, rpctypes.ErrCompacted)
+			_, err = cli.Get(ctx, "test-key", clientv3.WithRev(halfHourRev))
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("should keep KeepRevisions even when they are old", func(t *testing.T) {
+		TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+			storage := NewEtcdStorage(cli)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			c := &compactor{storage: storage, policy: CompactionPolicy{KeepRevisions: 2, KeepFor: time.Minute}, clock: fakeClock}
+
+			for _, name := range []string{"first", "second", "third"} {
+				require.NoError(t, storage.Create(ctx, "key-"+name, &TestObject{Name: name}))
+			}
+			require.NoError(t, c.compact(ctx))
+			current, err := storage.Revision(ctx)
+			require.NoError(t, err)
+
+			fakeClock.Step(time.Hour)
+			require.NoError(t, c.compact(ctx))
+
+			_, err = cli.Get(ctx, "key-first", clientv3.WithRev(current-2))
+			assert.NoError(t, err)
+			_, err = cli.Get(ctx, "key-first", clientv3.WithRev(current-3))
+			assert.ErrorIs(t, err, rpctypes.ErrCompacted)
+		})
+	})
+}
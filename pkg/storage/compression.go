@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gokube/pkg/runtime"
+)
+
+// compressedStorage gzip-compresses objects before they are stored in the Storage
+// it wraps
+type compressedStorage struct {
+	Storage
+}
+
+// NewCompressedStorage returns a Storage that gzip-compresses objects before s
+// stores them. The compressed bytes are stored as a base64 JSON string, since s
+// encodes whatever it is given as JSON. Values written without compression are
+// JSON objects rather than strings and can still be read back.
+func NewCompressedStorage(s Storage) Storage {
+	return &compressedStorage{Storage: s}
+}
+
+// compressedObject encodes obj compressed and decodes it from either form
+type compressedObject struct {
+	obj runtime.Object
+}
+
+func (c *compressedObject) MarshalJSON() ([]byte, error) {
+	data, err := runtime.Encode(c.obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(buf.Bytes())
+}
+
+func (c *compressedObject) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(`"`)) {
+		return runtime.Decode(data, c.obj)
+	}
+
+	var compressed []byte
+	if err := json.Unmarshal(data, &compressed); err != nil {
+		return err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+	return runtime.Decode(raw, c.obj)
+}
+
+func (s *compressedStorage) Create(ctx context.Context, key string, obj runtime.Object) error {
+	return s.Storage.Create(ctx, key, &compressedObject{obj: obj})
+}
+
+func (s *compressedStorage) Get(ctx context.Context, key string, obj runtime.Object) error {
+	return s.Storage.Get(ctx, key, &compressedObject{obj: obj})
+}
+
+func (s *compressedStorage) Update(ctx context.Context, key string, obj runtime.Object) error {
+	return s.Storage.Update(ctx, key, &compressedObject{obj: obj})
+}
+
+func (s *compressedStorage) GetWithRevision(ctx context.Context, key string, obj runtime.Object) (int64, error) {
+	return s.Storage.GetWithRevision(ctx, key, &compressedObject{obj: obj})
+}
+
+func (s *compressedStorage) UpdateIfRevision(ctx context.Context, key string, obj runtime.Object, revision int64) error {
+	return s.Storage.UpdateIfRevision(ctx, key, &compressedObject{obj: obj}, revision)
+}
+
+func (s *compressedStorage) GetAndDelete(ctx context.Context, key string, obj runtime.Object) error {
+	return s.Storage.GetAndDelete(ctx, key, &compressedObject{obj: obj})
+}
+
+// List reads the stored values as they are and decodes each into a new element of
+// listObj
+func (s *compressedStorage) List(ctx context.Context, prefix string, listObj interface{}) error {
+	listValue := reflect.ValueOf(listObj)
+	if listValue.Kind() != reflect.Ptr || listValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("listObj must be a pointer to a slice")
+	}
+
+	var values []*json.RawMessage
+	if err := s.Storage.List(ctx, prefix, &values); err != nil {
+		return err
+	}
+
+	sliceValue := listValue.Elem()
+	elementType := sliceValue.Type().Elem().Elem()
+	for _, value := range values {
+		ptr := reflect.New(elementType)
+		obj := &compressedObject{obj: ptr.Interface()}
+		if err := obj.UnmarshalJSON(*value); err != nil {
+			return fmt.Errorf("%w: %v", ErrDecoding, err)
+		}
+		sliceValue.Set(reflect.Append(sliceValue, ptr))
+	}
+	return nil
+}
+
+func (s *compressedStorage) Walk(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(obj runtime.Object) error) error {
+	return s.Storage.Walk(ctx, prefix, compressedNew(newObj), func(obj runtime.Object) error {
+		return fn(obj.(*compressedObject).obj)
+	})
+}
+
+func (s *compressedStorage) Scan(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(key string, obj runtime.Object, err error) error) error {
+	return s.Storage.Scan(ctx, prefix, compressedNew(newObj), func(key string, obj runtime.Object, err error) error {
+		return fn(key, obj.(*compressedObject).obj, err)
+	})
+}
+
+// compressedNew wraps the objects newObj returns in a compressedObject
+func compressedNew(newObj func() runtime.Object) func() runtime.Object {
+	return func() runtime.Object {
+		return &compressedObject{obj: newObj()}
+	}
+}
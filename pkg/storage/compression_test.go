@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/api"
+	"gokube/pkg/runtime"
+)
+
+func TestCompressedStorage(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		compressed := NewCompressedStorage(NewEtcdStorage(cli))
+		plain := NewEtcdStorage(cli)
+
+		t.Run("should read back a compressed node unchanged", func(t *testing.T) {
+			node := &api.Node{
+				ObjectMeta: api.ObjectMeta{
+					Name:   "test-node",
+					UID:    "123",
+					Labels: map[string]string{"zone": "a"},
+				},
+				Status: api.NodeReady,
+			}
+			require.NoError(t, compressed.Create(ctx, "/nodes/test-node", node))
+
+			resp, err := cli.Get(ctx, "/nodes/test-node")
+			require.NoError(t, err)
+			var stored []byte
+			require.NoError(t, json.Unmarshal(resp.Kvs[0].Value, &stored))
+			assert.True(t, bytes.HasPrefix(stored, []byte{0x1f, 0x8b}), "stored value should be gzipped")
+
+			var got api.Node
+			require.NoError(t, compressed.Get(ctx, "/nodes/test-node", &got))
+			assert.Equal(t, node, &got)
+		})
+
+		t.Run("should read legacy uncompressed values", func(t *testing.T) {
+			require.NoError(t, plain.Create(ctx, "/nodes/legacy-node", &TestObject{Name: "legacy"}))
+
+			var got TestObject
+			require.NoError(t, compressed.Get(ctx, "/nodes/legacy-node", &got))
+			assert.Equal(t, "legacy", got.Name)
+
+			var list []*TestObject
+			require.NoError(t, compressed.List(ctx, "/nodes/legacy", &list))
+			assert.Equal(t, []*TestObject{{Name: "legacy"}}, list)
+		})
+
+		t.Run("should walk compressed and legacy values alike", func(t *testing.T) {
+			require.NoError(t, compressed.Create(ctx, "/walk/a", &TestObject{Name: "a"}))
+			require.NoError(t, plain.Create(ctx, "/walk/b", &TestObject{Name: "b"}))
+
+			var names []string
+			err := compressed.Walk(ctx, "/walk/", func() runtime.Object { return &TestObject{} }, func(obj runtime.Object) error {
+				names = append(names, obj.(*TestObject).Name)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a", "b"}, names)
+		})
+	})
+}
@@ -2,14 +2,21 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 
 	"gokube/pkg/runtime"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// walkPageSize is the number of keys Walk fetches from etcd per request, a var so
+// tests can shrink it
+var walkPageSize int64 = 500
+
 // EtcdStorage implements the Storage interface using etcd
 type EtcdStorage struct {
 	client *clientv3.Client
@@ -21,39 +28,56 @@ func NewEtcdStorage(client *clientv3.Client) *EtcdStorage {
 }
 
 var (
-	ErrEncoding   = fmt.Errorf("error encoding object")
-	ErrDecoding   = fmt.Errorf("error decoding object")
-	ErrNotFound   = fmt.Errorf("object not found")
-	ErrEtcdClient = fmt.Errorf("etcd client error")
+	ErrEncoding      = fmt.Errorf("error encoding object")
+	ErrDecoding      = fmt.Errorf("error decoding object")
+	ErrNotFound      = fmt.Errorf("object not found")
+	ErrAlreadyExists = fmt.Errorf("object already exists")
+	ErrConflict      = fmt.Errorf("object was modified")
+	ErrEtcdClient    = fmt.Errorf("etcd client error")
 )
 
+// Create stores obj under key, failing with ErrAlreadyExists if the key is present.
+// The existence check and the write happen in a single transaction.
 func (s *EtcdStorage) Create(ctx context.Context, key string, obj runtime.Object) error {
 	data, err := runtime.Encode(obj)
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrEncoding, err)
 	}
 
-	_, err = s.client.Put(ctx, key, string(data))
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrEtcdClient, err)
 	}
+
+	if !resp.Succeeded {
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, key)
+	}
 	return nil
 }
 
 func (s *EtcdStorage) Get(ctx context.Context, key string, obj runtime.Object) error {
+	_, err := s.GetWithRevision(ctx, key, obj)
+	return err
+}
+
+// GetWithRevision returns the key's ModRevision
+func (s *EtcdStorage) GetWithRevision(ctx context.Context, key string, obj runtime.Object) (int64, error) {
 	resp, err := s.client.Get(ctx, key)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrEtcdClient, err)
+		return 0, fmt.Errorf("%w: %v", ErrEtcdClient, err)
 	}
 
 	if len(resp.Kvs) == 0 {
-		return fmt.Errorf("%w: %s", ErrNotFound, key)
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, key)
 	}
 
 	if err := runtime.Decode(resp.Kvs[0].Value, obj); err != nil {
-		return fmt.Errorf("%w: %v", ErrDecoding, err)
+		return 0, fmt.Errorf("%w: %v", ErrDecoding, err)
 	}
-	return nil
+	return resp.Kvs[0].ModRevision, nil
 }
 
 func (s *EtcdStorage) Update(ctx context.Context, key string, obj runtime.Object) error {
@@ -68,6 +92,37 @@ func (s *EtcdStorage) Update(ctx context.Context, key string, obj runtime.Object
 	return nil
 }
 
+// UpdateIfRevision compares the key's ModRevision and writes in a single transaction.
+// A key that was deleted in the meantime also fails the comparison.
+func (s *EtcdStorage) UpdateIfRevision(ctx context.Context, key string, obj runtime.Object, revision int64) error {
+	data, err := runtime.Encode(obj)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEncoding, err)
+	}
+
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", revision)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEtcdClient, err)
+	}
+
+	if !resp.Succeeded {
+		return fmt.Errorf("%w: %s", ErrConflict, key)
+	}
+	return nil
+}
+
+// Exists asks etcd for the number of matching keys only, so the value is never sent
+func (s *EtcdStorage) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := s.client.Get(ctx, key, clientv3.WithCountOnly())
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrEtcdClient, err)
+	}
+	return resp.Count > 0, nil
+}
+
 func (s *EtcdStorage) Delete(ctx context.Context, key string) error {
 	if _, err := s.client.Delete(ctx, key); err != nil {
 		return fmt.Errorf("%w: %v", ErrEtcdClient, err)
@@ -76,6 +131,24 @@ func (s *EtcdStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// GetAndDelete takes the deleted value from the same request that deletes it
+func (s *EtcdStorage) GetAndDelete(ctx context.Context, key string, obj runtime.Object) error {
+	resp, err := s.client.Delete(ctx, key, clientv3.WithPrevKV())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEtcdClient, err)
+	}
+
+	if len(resp.PrevKvs) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	if err := runtime.Decode(resp.PrevKvs[0].Value, obj); err != nil {
+		return fmt.Errorf("%w: %v", ErrDecoding, err)
+	}
+	return nil
+}
+
+// List relies on etcd returning range results sorted by key
 func (s *EtcdStorage) List(ctx context.Context, prefix string, listObj interface{}) error {
 	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix())
 	if err != nil {
@@ -87,18 +160,106 @@ func (s *EtcdStorage) List(ctx context.Context, prefix string, listObj interface
 		return fmt.Errorf("listObj must be a pointer to a slice")
 	}
 
-	sliceValue := listValue.Elem()
+	return s.decodeList(listValue.Elem(), resp.Kvs)
+}
+
+// decodeList decodes kvs and appends them to sliceValue, a slice of pointers. The
+// number of results is known up front, so the slice is grown once and the objects
+// are decoded into a single backing array instead of being allocated one by one.
+func (s *EtcdStorage) decodeList(sliceValue reflect.Value, kvs []*mvccpb.KeyValue) error {
 	elementType := sliceValue.Type().Elem()
+	n := sliceValue.Len()
+	list := reflect.MakeSlice(sliceValue.Type(), n, n+len(kvs))
+	reflect.Copy(list, sliceValue)
 
-	for _, kv := range resp.Kvs {
-		obj := reflect.New(elementType.Elem()).Interface().(runtime.Object)
-		if err := runtime.Decode(kv.Value, obj); err != nil {
+	objs := reflect.MakeSlice(reflect.SliceOf(elementType.Elem()), len(kvs), len(kvs))
+	for i, kv := range kvs {
+		ptr := objs.Index(i).Addr()
+		if err := runtime.Decode(kv.Value, ptr.Interface().(runtime.Object)); err != nil {
 			return fmt.Errorf("%w: %v", ErrDecoding, err)
 		}
-		sliceValue = reflect.Append(sliceValue, reflect.ValueOf(obj))
+		list = reflect.Append(list, ptr)
+	}
+
+	sliceValue.Set(list)
+	return nil
+}
+
+// Walk reads all pages at the revision of the first one, so the objects passed to fn
+// form a consistent snapshot even if keys change while the walk is in progress
+func (s *EtcdStorage) Walk(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(obj runtime.Object) error) error {
+	return s.Scan(ctx, prefix, newObj, func(_ string, obj runtime.Object, err error) error {
+		if err != nil {
+			return err
+		}
+		return fn(obj)
+	})
+}
+
+// Scan reads the objects under prefix in pages, like Walk, reporting decoding
+// failures as ErrDecoding errors to fn
+func (s *EtcdStorage) Scan(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(key string, obj runtime.Object, err error) error) error {
+	opts := []clientv3.OpOption{
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix)),
+		clientv3.WithLimit(walkPageSize),
+	}
+
+	key := prefix
+	for {
+		resp, err := s.client.Get(ctx, key, opts...)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrEtcdClient, err)
+		}
+
+		for _, kv := range resp.Kvs {
+			obj := newObj()
+			var decodeErr error
+			if err := runtime.Decode(kv.Value, obj); err != nil {
+				decodeErr = fmt.Errorf("%w: %v", ErrDecoding, err)
+			}
+			if err := fn(string(kv.Key), obj, decodeErr); err != nil {
+				return err
+			}
+		}
+
+		if !resp.More || len(resp.Kvs) == 0 {
+			return nil
+		}
+
+		// Continue just after the last key, pinned to the first page's revision
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+		if len(opts) == 2 {
+			opts = append(opts, clientv3.WithRev(resp.Header.Revision))
+		}
 	}
+}
 
-	listValue.Elem().Set(sliceValue)
+// Revision returns etcd's current revision
+func (s *EtcdStorage) Revision(ctx context.Context) (int64, error) {
+	// Any read reports the current revision in its header
+	resp, err := s.client.Get(ctx, "/", clientv3.WithCountOnly())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrEtcdClient, err)
+	}
+	return resp.Header.Revision, nil
+}
+
+// Compact compacts etcd's MVCC history up to keepRevisions before the current
+// revision. Etcd compacts its whole keyspace, whatever the prefix of this
+// storage's keys. Reads and watches at a compacted revision fail afterwards.
+func (s *EtcdStorage) Compact(ctx context.Context, keepRevisions int) error {
+	current, err := s.Revision(ctx)
+	if err != nil {
+		return err
+	}
+
+	rev := current - int64(keepRevisions)
+	if rev <= 0 {
+		return nil
+	}
+	if _, err := s.client.Compact(ctx, rev); err != nil && !errors.Is(err, rpctypes.ErrCompacted) {
+		return fmt.Errorf("%w: %v", ErrEtcdClient, err)
+	}
 	return nil
 }
 
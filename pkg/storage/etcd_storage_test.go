@@ -2,12 +2,18 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/runtime"
 )
 
 type TestObject struct {
@@ -32,6 +38,79 @@ func TestEtcdStorage_Create(t *testing.T) {
 	})
 }
 
+func TestEtcdStorage_CreateExisting(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err := storage.Create(ctx, "test-key", &TestObject{Name: "first"})
+		assert.NoError(t, err)
+
+		err = storage.Create(ctx, "test-key", &TestObject{Name: "second"})
+		assert.ErrorIs(t, err, ErrAlreadyExists)
+
+		var retrievedObj TestObject
+		err = storage.Get(ctx, "test-key", &retrievedObj)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", retrievedObj.Name)
+	})
+}
+
+func TestEtcdStorage_Exists(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err := storage.Create(ctx, "test-key", &TestObject{Name: "test-value"})
+		assert.NoError(t, err)
+
+		exists, err := storage.Exists(ctx, "test-key")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = storage.Exists(ctx, "missing-key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestEtcdStorage_Compact(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err := storage.Create(ctx, "test-key", &TestObject{Name: "first"})
+		require.NoError(t, err)
+		resp, err := cli.Get(ctx, "test-key")
+		require.NoError(t, err)
+		oldRev := resp.Header.Revision
+
+		for _, name := range []string{"second", "third"} {
+			err := storage.Update(ctx, "test-key", &TestObject{Name: name})
+			require.NoError(t, err)
+		}
+
+		// Keep one revision before the current one, which drops oldRev
+		err = storage.Compact(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = cli.Get(ctx, "test-key", clientv3.WithRev(oldRev))
+		assert.ErrorIs(t, err, rpctypes.ErrCompacted)
+
+		var retrievedObj TestObject
+		err = storage.Get(ctx, "test-key", &retrievedObj)
+		assert.NoError(t, err)
+		assert.Equal(t, "third", retrievedObj.Name)
+
+		// Compacting again to the same revision is not an error
+		err = storage.Compact(ctx, 1)
+		assert.NoError(t, err)
+	})
+}
+
 func TestEtcdStorage_Update(t *testing.T) {
 	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
 		storage := NewEtcdStorage(cli)
@@ -54,6 +133,28 @@ func TestEtcdStorage_Update(t *testing.T) {
 	})
 }
 
+func TestEtcdStorage_UpdateIfRevision(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		require.NoError(t, storage.Create(ctx, "test-key", &TestObject{Name: "test-value"}))
+		var obj TestObject
+		revision, err := storage.GetWithRevision(ctx, "test-key", &obj)
+		require.NoError(t, err)
+
+		require.NoError(t, storage.UpdateIfRevision(ctx, "test-key", &TestObject{Name: "first"}, revision))
+
+		// The write above moved the key past revision
+		err = storage.UpdateIfRevision(ctx, "test-key", &TestObject{Name: "second"}, revision)
+		assert.ErrorIs(t, err, ErrConflict)
+
+		require.NoError(t, storage.Get(ctx, "test-key", &obj))
+		assert.Equal(t, "first", obj.Name)
+	})
+}
+
 func TestEtcdStorage_Delete(t *testing.T) {
 	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
 		storage := NewEtcdStorage(cli)
@@ -96,6 +197,130 @@ func TestEtcdStorage_List(t *testing.T) {
 	})
 }
 
+func TestEtcdStorage_decodeList(t *testing.T) {
+	storage := &EtcdStorage{}
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("/prefix/key2"), Value: []byte(`{"name":"value2"}`)},
+		{Key: []byte("/prefix/key3"), Value: []byte(`{"name":"value3"}`)},
+	}
+
+	t.Run("should append decoded objects after existing elements", func(t *testing.T) {
+		list := []*TestObject{{Name: "value1"}}
+		require.NoError(t, storage.decodeList(reflect.ValueOf(&list).Elem(), kvs))
+		assert.Equal(t, []*TestObject{{Name: "value1"}, {Name: "value2"}, {Name: "value3"}}, list)
+	})
+
+	t.Run("should report undecodable values", func(t *testing.T) {
+		var list []*TestObject
+		err := storage.decodeList(reflect.ValueOf(&list).Elem(), []*mvccpb.KeyValue{{Value: []byte("{")}})
+		assert.ErrorIs(t, err, ErrDecoding)
+	})
+}
+
+func BenchmarkEtcdStorage_decodeList(b *testing.B) {
+	storage := &EtcdStorage{}
+	kvs := make([]*mvccpb.KeyValue, 10000)
+	for i := range kvs {
+		kvs[i] = &mvccpb.KeyValue{
+			Key:   []byte(fmt.Sprintf("/registry/nodes/node-%05d", i)),
+			Value: []byte(fmt.Sprintf(`{"name":"node-%05d"}`, i)),
+		}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var list []*TestObject
+		if err := storage.decodeList(reflect.ValueOf(&list).Elem(), kvs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEtcdStorage_ListOrder(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Written out of order, and "key10" sorts before "key2" byte-wise
+		for _, name := range []string{"key2", "key10", "Key3", "key1"} {
+			err := storage.Create(ctx, "/prefix/"+name, &TestObject{Name: name})
+			require.NoError(t, err)
+		}
+
+		var list []*TestObject
+		err := storage.List(ctx, "/prefix/", &list)
+		require.NoError(t, err)
+
+		var names []string
+		for _, obj := range list {
+			names = append(names, obj.Name)
+		}
+		assert.Equal(t, []string{"Key3", "key1", "key10", "key2"}, names)
+	})
+}
+
+func TestEtcdStorage_Walk(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		oldPageSize := walkPageSize
+		walkPageSize = 2
+		defer func() { walkPageSize = oldPageSize }()
+
+		names := []string{"value1", "value2", "value3", "value4", "value5"}
+		for _, name := range names {
+			err := storage.Create(ctx, "/prefix/"+name, &TestObject{Name: name})
+			assert.NoError(t, err)
+		}
+		err := storage.Create(ctx, "/other/value6", &TestObject{Name: "value6"})
+		assert.NoError(t, err)
+
+		var walked []string
+		err = storage.Walk(ctx, "/prefix/", func() runtime.Object { return &TestObject{} }, func(obj runtime.Object) error {
+			walked = append(walked, obj.(*TestObject).Name)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, names, walked)
+	})
+}
+
+func TestEtcdStorage_Scan(t *testing.T) {
+	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+		storage := NewEtcdStorage(cli)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		require.NoError(t, storage.Create(ctx, "/prefix/value1", &TestObject{Name: "value1"}))
+		_, err := cli.Put(ctx, "/prefix/value2", "{corrupt")
+		require.NoError(t, err)
+		require.NoError(t, storage.Create(ctx, "/prefix/value3", &TestObject{Name: "value3"}))
+
+		var scanned, failed []string
+		err = storage.Scan(ctx, "/prefix/", func() runtime.Object { return &TestObject{} }, func(key string, obj runtime.Object, err error) error {
+			if err != nil {
+				assert.ErrorIs(t, err, ErrDecoding)
+				failed = append(failed, key)
+				return nil
+			}
+			scanned = append(scanned, key)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/prefix/value1", "/prefix/value3"}, scanned)
+		assert.Equal(t, []string{"/prefix/value2"}, failed)
+
+		// Walk stops at the value that can't be decoded
+		err = storage.Walk(ctx, "/prefix/", func() runtime.Object { return &TestObject{} }, func(obj runtime.Object) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrDecoding)
+	})
+}
+
 func TestWatch(t *testing.T) {
 	TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
 		watchKey := "/watch-test/key"
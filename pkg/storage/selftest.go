@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// SelfTestPrefix is reserved for the keys SelfTest writes; no other data may be
+// stored under it
+const SelfTestPrefix = "/gokube/selftest/"
+
+// ErrSelfTestFailed is returned by SelfTest when s doesn't behave like a Storage
+var ErrSelfTestFailed = errors.New("storage self-test failed")
+
+// selfTestObject is the value SelfTest writes
+type selfTestObject struct {
+	Value string `json:"value"`
+}
+
+// SelfTest creates, reads, updates and deletes a sentinel key under SelfTestPrefix
+// to find out early whether s is usable, e.g. at startup. The key is random, so
+// servers sharing a backend don't interfere, and it is removed again even if a
+// step fails.
+func SelfTest(ctx context.Context, s Storage) (err error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("%w: %v", ErrSelfTestFailed, err)
+	}
+	key := SelfTestPrefix + hex.EncodeToString(b)
+
+	if err := s.Create(ctx, key, &selfTestObject{Value: "created"}); err != nil {
+		return fmt.Errorf("%w: create %s: %w", ErrSelfTestFailed, key, err)
+	}
+	defer func() {
+		if deleteErr := s.Delete(ctx, key); deleteErr != nil && err == nil {
+			err = fmt.Errorf("%w: delete %s: %w", ErrSelfTestFailed, key, deleteErr)
+		}
+	}()
+
+	if err := selfTestRead(ctx, s, key, "created"); err != nil {
+		return err
+	}
+	if err := s.Update(ctx, key, &selfTestObject{Value: "updated"}); err != nil {
+		return fmt.Errorf("%w: update %s: %w", ErrSelfTestFailed, key, err)
+	}
+	return selfTestRead(ctx, s, key, "updated")
+}
+
+// selfTestRead checks that key holds want
+func selfTestRead(ctx context.Context, s Storage, key, want string) error {
+	var got selfTestObject
+	if err := s.Get(ctx, key, &got); err != nil {
+		return fmt.Errorf("%w: get %s: %w", ErrSelfTestFailed, key, err)
+	}
+	if got.Value != want {
+		return fmt.Errorf("%w: get %s returned %q, expected %q", ErrSelfTestFailed, key, got.Value, want)
+	}
+	return nil
+}
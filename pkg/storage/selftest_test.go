@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"gokube/pkg/runtime"
+)
+
+// readOnlyStorage fails every update, like a backend that rejects writes
+type readOnlyStorage struct {
+	Storage
+}
+
+func (readOnlyStorage) Update(context.Context, string, runtime.Object) error {
+	return errors.New("etcdserver: permission denied")
+}
+
+func TestSelfTest(t *testing.T) {
+	t.Run("should pass and clean up on a working storage", func(t *testing.T) {
+		TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			require.NoError(t, SelfTest(ctx, NewEtcdStorage(cli)))
+
+			resp, err := cli.Get(ctx, SelfTestPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+			require.NoError(t, err)
+			assert.Zero(t, resp.Count)
+		})
+	})
+
+	t.Run("should say which step failed on a broken storage", func(t *testing.T) {
+		TestWithEmbeddedEtcd(t, func(t *testing.T, cli *clientv3.Client) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := SelfTest(ctx, readOnlyStorage{Storage: NewEtcdStorage(cli)})
+			require.ErrorIs(t, err, ErrSelfTestFailed)
+			assert.Contains(t, err.Error(), "update "+SelfTestPrefix)
+			assert.Contains(t, err.Error(), "permission denied")
+
+			// The sentinel is removed even though the test failed
+			resp, err := cli.Get(ctx, SelfTestPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+			require.NoError(t, err)
+			assert.Zero(t, resp.Count)
+		})
+	})
+}
@@ -13,7 +13,34 @@ type Storage interface {
 	Create(ctx context.Context, key string, obj runtime.Object) error
 	Get(ctx context.Context, key string, obj runtime.Object) error
 	Update(ctx context.Context, key string, obj runtime.Object) error
+	// GetWithRevision is like Get but also returns the revision obj was last written
+	// at, for use with UpdateIfRevision
+	GetWithRevision(ctx context.Context, key string, obj runtime.Object) (int64, error)
+	// UpdateIfRevision writes obj under key only if key was last written at revision,
+	// failing with ErrConflict otherwise. The check and the write are atomic.
+	UpdateIfRevision(ctx context.Context, key string, obj runtime.Object, revision int64) error
 	Delete(ctx context.Context, key string) error
+	// GetAndDelete removes key and decodes the value it had into obj, failing with
+	// ErrNotFound if there was nothing to delete
+	GetAndDelete(ctx context.Context, key string, obj runtime.Object) error
+	// Exists reports whether an object is stored under key without fetching it
+	Exists(ctx context.Context, key string) (bool, error)
 	DeletePrefix(ctx context.Context, prefix string) error
+	// List decodes all objects under prefix into listObj, a pointer to a slice of
+	// pointers. Objects are ordered by their full key, compared byte-wise,
+	// regardless of the order they were written in; backends must keep this order.
 	List(ctx context.Context, prefix string, listObj interface{}) error
+	// Walk decodes each object under prefix, in key order, into a value returned by
+	// newObj and passes it to fn. Objects are fetched in pages so the whole result
+	// is never held in memory. Returning an error from fn stops the walk.
+	Walk(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(obj runtime.Object) error) error
+	// Scan is like Walk but also passes each object's key, and passes objects that
+	// fail to decode to fn along with the decoding error instead of stopping
+	Scan(ctx context.Context, prefix string, newObj func() runtime.Object, fn func(key string, obj runtime.Object, err error) error) error
+	// Revision returns the backend's current revision
+	Revision(ctx context.Context) (int64, error)
+	// Compact discards the history of all but the last keepRevisions revisions.
+	// It applies to the whole backend, not just the keys this server writes; a
+	// compacted etcd loses the history of every tenant sharing it.
+	Compact(ctx context.Context, keepRevisions int) error
 }
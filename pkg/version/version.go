@@ -0,0 +1,49 @@
+// Package version reports which build of gokube is running. Version and GitCommit
+// are set at build time with
+//
+//	-ldflags "-X gokube/pkg/version.Version=v0.1.0 -X gokube/pkg/version.GitCommit=abc123"
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	// Version is the release version of the build
+	Version = ""
+	// GitCommit is the commit the build was made from
+	GitCommit = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build info, falling back to what the Go toolchain recorded in the
+// binary when the ldflags weren't set
+func Get() Info {
+	info := Info{Version: Version, GitCommit: GitCommit, GoVersion: runtime.Version()}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" && info.GitCommit == "" {
+				info.GitCommit = setting.Value
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	return info
+}
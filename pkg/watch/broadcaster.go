@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"net/http"
+	"sync"
+
+	"gokube/pkg/api"
+	"gokube/pkg/runtime"
+)
+
+// DefaultBufferSize is the number of events buffered per watcher when none is configured
+const DefaultBufferSize = 100
+
+// Broadcaster distributes events to any number of watchers. Each watcher has its
+// own bounded buffer; a watcher whose buffer fills up is disconnected with a
+// terminal Error event so that one slow consumer never stalls the others.
+type Broadcaster struct {
+	mu         sync.Mutex
+	watchers   map[int64]*broadcasterWatcher
+	nextID     int64
+	bufferSize int
+	stopped    bool
+}
+
+// NewBroadcaster creates a Broadcaster buffering up to bufferSize events per watcher.
+// A bufferSize <= 0 uses DefaultBufferSize.
+func NewBroadcaster(bufferSize int) *Broadcaster {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Broadcaster{
+		watchers:   make(map[int64]*broadcasterWatcher),
+		bufferSize: bufferSize,
+	}
+}
+
+// Watch adds a new watcher to the list and returns an Interface for it
+func (b *Broadcaster) Watch() Interface {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// One extra slot is reserved so the terminal event always fits
+	w := &broadcasterWatcher{
+		result: make(chan Event, b.bufferSize+1),
+		id:     b.nextID,
+		b:      b,
+	}
+	b.nextID++
+
+	if b.stopped {
+		close(w.result)
+		return w
+	}
+
+	b.watchers[w.id] = w
+	return w
+}
+
+// Action distributes the given event to all watchers without blocking
+func (b *Broadcaster) Action(eventType EventType, obj runtime.Object) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := Event{Type: eventType, Object: obj}
+	for id, w := range b.watchers {
+		if len(w.result) >= b.bufferSize {
+			w.result <- Event{Type: Error, Object: &api.Status{
+				Status:  api.StatusFailure,
+				Message: "watcher fell too far behind and was disconnected",
+				Reason:  api.StatusReasonExpired,
+				Code:    http.StatusGone,
+			}}
+			delete(b.watchers, id)
+			close(w.result)
+			continue
+		}
+
+		w.result <- event
+	}
+}
+
+// Shutdown disconnects all watchers. Further calls to Watch return closed watchers.
+func (b *Broadcaster) Shutdown() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, w := range b.watchers {
+		delete(b.watchers, id)
+		close(w.result)
+	}
+	b.stopped = true
+}
+
+func (b *Broadcaster) stopWatching(id int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w, ok := b.watchers[id]
+	if !ok {
+		// Already disconnected by Action or Shutdown
+		return
+	}
+	delete(b.watchers, id)
+	close(w.result)
+}
+
+// broadcasterWatcher handles a single watcher of a Broadcaster
+type broadcasterWatcher struct {
+	result chan Event
+	id     int64
+	b      *Broadcaster
+}
+
+// ResultChan returns a channel to use for waiting on events
+func (w *broadcasterWatcher) ResultChan() <-chan Event {
+	return w.result
+}
+
+// Stop stops watching and removes w from the Broadcaster
+func (w *broadcasterWatcher) Stop() {
+	w.b.stopWatching(w.id)
+}
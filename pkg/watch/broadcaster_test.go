@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gokube/pkg/api"
+)
+
+func TestBroadcaster(t *testing.T) {
+	t.Run("should deliver events to all watchers", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w1 := b.Watch()
+		w2 := b.Watch()
+
+		node := &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}}
+		b.Action(Added, node)
+
+		for _, w := range []Interface{w1, w2} {
+			event := <-w.ResultChan()
+			assert.Equal(t, Added, event.Type)
+			assert.Equal(t, node, event.Object)
+		}
+	})
+
+	t.Run("should disconnect a slow watcher without stalling others", func(t *testing.T) {
+		bufferSize := 5
+		b := NewBroadcaster(bufferSize)
+		defer b.Shutdown()
+
+		slow := b.Watch()
+		healthy := b.Watch()
+
+		total := bufferSize * 3
+		for i := 0; i < total; i++ {
+			b.Action(Modified, &api.Node{ObjectMeta: api.ObjectMeta{Name: "test-node"}})
+
+			select {
+			case event := <-healthy.ResultChan():
+				assert.Equal(t, Modified, event.Type)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("healthy watcher stopped receiving events after %d events", i)
+			}
+		}
+
+		// The slow watcher gets everything that fit in its buffer, then a terminal event
+		var events []Event
+		for event := range slow.ResultChan() {
+			events = append(events, event)
+		}
+		require.Len(t, events, bufferSize+1)
+		for _, event := range events[:bufferSize] {
+			assert.Equal(t, Modified, event.Type)
+		}
+
+		terminal := events[bufferSize]
+		assert.Equal(t, Error, terminal.Type)
+		status, ok := terminal.Object.(*api.Status)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusGone, status.Code)
+		assert.Equal(t, api.StatusReasonExpired, status.Reason)
+	})
+
+	t.Run("should close the result channel on stop", func(t *testing.T) {
+		b := NewBroadcaster(0)
+		defer b.Shutdown()
+
+		w := b.Watch()
+		w.Stop()
+		w.Stop()
+
+		_, ok := <-w.ResultChan()
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,132 @@
+package watch
+
+import (
+	"sync"
+	"time"
+
+	"gokube/pkg/runtime"
+)
+
+// KeyFunc returns the identity of an event object, such as its name. Events whose
+// key is empty are never coalesced.
+type KeyFunc func(obj runtime.Object) string
+
+// Coalesce returns a watch that collapses Modified events for the same key arriving
+// within window into the latest one. Any other event type, Deleted in particular,
+// first flushes the pending Modified events and is then delivered unchanged, so
+// ordering between a change and a later delete is kept. Stopping the returned
+// watch stops w.
+func Coalesce(w Interface, window time.Duration, key KeyFunc) Interface {
+	c := &coalescingWatcher{
+		w:      w,
+		window: window,
+		key:    key,
+		result: make(chan Event),
+		stop:   make(chan struct{}),
+		index:  make(map[string]int),
+	}
+	go c.run()
+	return c
+}
+
+type coalescingWatcher struct {
+	w        Interface
+	window   time.Duration
+	key      KeyFunc
+	result   chan Event
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// pending holds the held back Modified events in arrival order, index maps
+	// their keys to positions in pending
+	pending []Event
+	index   map[string]int
+}
+
+func (c *coalescingWatcher) run() {
+	defer close(c.result)
+
+	// The timer runs while there are pending events, all of which are flushed
+	// together when it fires
+	var timer *time.Timer
+	var flushAt <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-c.w.ResultChan():
+			if !ok {
+				c.flush()
+				return
+			}
+
+			key := ""
+			if event.Type == Modified {
+				key = c.key(event.Object)
+			}
+			if key == "" {
+				if !c.flush() || !c.send(event) {
+					return
+				}
+				continue
+			}
+
+			if i, ok := c.index[key]; ok {
+				c.pending[i] = event
+				continue
+			}
+			c.index[key] = len(c.pending)
+			c.pending = append(c.pending, event)
+			if flushAt == nil {
+				timer = time.NewTimer(c.window)
+				flushAt = timer.C
+			}
+		case <-flushAt:
+			timer, flushAt = nil, nil
+			if !c.flush() {
+				return
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// flush sends all pending events and reports whether the watch is still running
+func (c *coalescingWatcher) flush() bool {
+	pending := c.pending
+	c.pending = nil
+	clear(c.index)
+	for _, event := range pending {
+		if !c.send(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *coalescingWatcher) send(event Event) bool {
+	select {
+	case c.result <- event:
+		return true
+	case <-c.stop:
+		return false
+	}
+}
+
+// Stop implements Interface
+func (c *coalescingWatcher) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		c.w.Stop()
+	})
+}
+
+// ResultChan implements Interface
+func (c *coalescingWatcher) ResultChan() <-chan Event {
+	return c.result
+}
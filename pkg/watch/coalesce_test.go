@@ -0,0 +1,93 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gokube/pkg/api"
+	"gokube/pkg/runtime"
+)
+
+func nodeName(obj runtime.Object) string {
+	if node, ok := obj.(*api.Node); ok {
+		return node.Name
+	}
+	return ""
+}
+
+func heartbeat(name, uid string) *api.Node {
+	return &api.Node{ObjectMeta: api.ObjectMeta{Name: name, UID: uid}}
+}
+
+func TestCoalesce(t *testing.T) {
+	t.Run("should collapse rapid modifications into the latest one", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w := Coalesce(b.Watch(), 50*time.Millisecond, nodeName)
+		defer w.Stop()
+
+		b.Action(Modified, heartbeat("test-node", "1"))
+		b.Action(Modified, heartbeat("test-node", "2"))
+		b.Action(Modified, heartbeat("test-node", "3"))
+
+		event := <-w.ResultChan()
+		assert.Equal(t, Modified, event.Type)
+		assert.Equal(t, "3", event.Object.(*api.Node).UID)
+
+		select {
+		case event := <-w.ResultChan():
+			t.Fatalf("unexpected event %v", event)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("should keep modifications of different nodes apart", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w := Coalesce(b.Watch(), 50*time.Millisecond, nodeName)
+		defer w.Stop()
+
+		b.Action(Modified, heartbeat("test-node-1", "1"))
+		b.Action(Modified, heartbeat("test-node-2", "2"))
+
+		assert.Equal(t, "test-node-1", (<-w.ResultChan()).Object.(*api.Node).Name)
+		assert.Equal(t, "test-node-2", (<-w.ResultChan()).Object.(*api.Node).Name)
+	})
+
+	t.Run("should never coalesce away a delete", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w := Coalesce(b.Watch(), time.Hour, nodeName)
+		defer w.Stop()
+
+		b.Action(Modified, heartbeat("test-node", "1"))
+		b.Action(Modified, heartbeat("test-node", "2"))
+		b.Action(Deleted, heartbeat("test-node", "2"))
+
+		event := <-w.ResultChan()
+		assert.Equal(t, Modified, event.Type)
+		assert.Equal(t, "2", event.Object.(*api.Node).UID)
+		assert.Equal(t, Deleted, (<-w.ResultChan()).Type)
+	})
+
+	t.Run("should flush pending events when the source closes", func(t *testing.T) {
+		b := NewBroadcaster(10)
+
+		w := Coalesce(b.Watch(), time.Hour, nodeName)
+		defer w.Stop()
+
+		b.Action(Modified, heartbeat("test-node", "1"))
+		b.Shutdown()
+
+		var events []Event
+		for event := range w.ResultChan() {
+			events = append(events, event)
+		}
+		assert.Len(t, events, 1)
+	})
+}
@@ -0,0 +1,50 @@
+package watch
+
+import "sync"
+
+// Filter returns a watch that delivers only the events of w that keep returns true
+// for. Stopping the returned watch stops w.
+func Filter(w Interface, keep func(event Event) bool) Interface {
+	f := &filterWatcher{
+		w:      w,
+		result: make(chan Event),
+		stop:   make(chan struct{}),
+	}
+	go f.run(keep)
+	return f
+}
+
+type filterWatcher struct {
+	w        Interface
+	result   chan Event
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (f *filterWatcher) run(keep func(event Event) bool) {
+	defer close(f.result)
+
+	for event := range f.w.ResultChan() {
+		if !keep(event) {
+			continue
+		}
+		select {
+		case f.result <- event:
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// Stop implements Interface
+func (f *filterWatcher) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+		f.w.Stop()
+	})
+}
+
+// ResultChan implements Interface
+func (f *filterWatcher) ResultChan() <-chan Event {
+	return f.result
+}
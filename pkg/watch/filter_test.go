@@ -0,0 +1,39 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gokube/pkg/api"
+)
+
+func TestFilter(t *testing.T) {
+	t.Run("should only deliver the events that are kept", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w := Filter(b.Watch(), func(event Event) bool {
+			return event.Object.(*api.Node).Name == "kept"
+		})
+		defer w.Stop()
+
+		b.Action(Added, &api.Node{ObjectMeta: api.ObjectMeta{Name: "dropped"}})
+		b.Action(Added, &api.Node{ObjectMeta: api.ObjectMeta{Name: "kept"}})
+
+		event := <-w.ResultChan()
+		assert.Equal(t, "kept", event.Object.(*api.Node).Name)
+	})
+
+	t.Run("should close the result channel on stop", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w := Filter(b.Watch(), func(Event) bool { return true })
+		w.Stop()
+		w.Stop()
+
+		for range w.ResultChan() {
+		}
+	})
+}
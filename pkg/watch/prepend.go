@@ -0,0 +1,59 @@
+package watch
+
+import "sync"
+
+// Prepend returns a watch that delivers events first and then everything w delivers.
+// Stopping the returned watch stops w.
+func Prepend(w Interface, events []Event) Interface {
+	p := &prependWatcher{
+		w:      w,
+		result: make(chan Event),
+		stop:   make(chan struct{}),
+	}
+	go p.run(events)
+	return p
+}
+
+type prependWatcher struct {
+	w        Interface
+	result   chan Event
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (p *prependWatcher) run(events []Event) {
+	defer close(p.result)
+
+	for _, event := range events {
+		if !p.send(event) {
+			return
+		}
+	}
+	for event := range p.w.ResultChan() {
+		if !p.send(event) {
+			return
+		}
+	}
+}
+
+func (p *prependWatcher) send(event Event) bool {
+	select {
+	case p.result <- event:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+// Stop implements Interface
+func (p *prependWatcher) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		p.w.Stop()
+	})
+}
+
+// ResultChan implements Interface
+func (p *prependWatcher) ResultChan() <-chan Event {
+	return p.result
+}
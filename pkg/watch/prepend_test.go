@@ -0,0 +1,42 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gokube/pkg/api"
+)
+
+func TestPrepend(t *testing.T) {
+	t.Run("should deliver the prepended events before live ones", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		initial := []Event{
+			{Type: Added, Object: &api.Node{ObjectMeta: api.ObjectMeta{Name: "existing"}}},
+			{Type: Bookmark},
+		}
+		w := Prepend(b.Watch(), initial)
+		defer w.Stop()
+
+		b.Action(Added, &api.Node{ObjectMeta: api.ObjectMeta{Name: "new"}})
+
+		assert.Equal(t, initial[0], <-w.ResultChan())
+		assert.Equal(t, initial[1], <-w.ResultChan())
+		event := <-w.ResultChan()
+		assert.Equal(t, "new", event.Object.(*api.Node).Name)
+	})
+
+	t.Run("should close the result channel on stop", func(t *testing.T) {
+		b := NewBroadcaster(10)
+		defer b.Shutdown()
+
+		w := Prepend(b.Watch(), []Event{{Type: Bookmark}})
+		w.Stop()
+		w.Stop()
+
+		for range w.ResultChan() {
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package watch
+
+import (
+	"gokube/pkg/runtime"
+)
+
+// Interface can be implemented by anything that knows how to watch and report changes
+type Interface interface {
+	// Stop stops watching and closes the channel returned by ResultChan
+	Stop()
+
+	// ResultChan returns a channel which receives all the events
+	ResultChan() <-chan Event
+}
+
+// EventType defines the possible types of events
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Error    EventType = "ERROR"
+	// Bookmark carries no change; it marks a point in the stream, such as the end
+	// of the initial events
+	Bookmark EventType = "BOOKMARK"
+)
+
+// InitialEventsEndAnnotation is set on the object of the Bookmark that follows the
+// initial events of a watch
+const InitialEventsEndAnnotation = "gokube.io/initial-events-end"
+
+// Event represents a single event to a watched resource
+type Event struct {
+	Type EventType `json:"type"`
+
+	// Object is the object from the event. For Error events it is an *api.Status
+	// describing why the watch was terminated
+	Object runtime.Object `json:"object"`
+}